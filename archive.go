@@ -0,0 +1,217 @@
+package httgo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultExtractMaxBytes = 1 << 30
+	defaultExtractMaxFiles = 10000
+)
+
+var (
+	// ErrUnsafePath is returned for archive entries escaping the destination directory
+	ErrUnsafePath = errors.New("Unsafe Archive Entry Path")
+	// ErrArchiveTooLarge is returned when an archive exceeds the extraction limits
+	ErrArchiveTooLarge = errors.New("Archive Too Large")
+)
+
+// SetExtractLimits bounds the total extracted bytes and number of entries of Untar and Unzip,
+// defaults are 1GiB and 10000 entries
+func (b *RequestBuilder) SetExtractLimits(maxBytes int64, maxFiles int) *RequestBuilder {
+	b.extractMaxBytes = maxBytes
+	b.extractMaxFiles = maxFiles
+	return b
+}
+
+// Untar streams a tar (optionally gzipped) response body into dir.
+// Symlinks and other special entries are skipped.
+func (b *RequestBuilder) Untar(dir string) []error {
+	res := b.response()
+	if res == nil || !b.downloadable(res) {
+		return b.errs
+	}
+	defer res.Body.Close()
+
+	err := b.untar(res.Body, dir)
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return b.errs
+}
+
+// Unzip spools a zip response body to a temporary file, since zip needs random access,
+// and extracts it into dir. Symlinks and other special entries are skipped.
+func (b *RequestBuilder) Unzip(dir string) []error {
+	res := b.response()
+	if res == nil || !b.downloadable(res) {
+		return b.errs
+	}
+	defer res.Body.Close()
+
+	err := b.unzip(res.Body, dir)
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return b.errs
+}
+
+func (b *RequestBuilder) extractLimits() (int64, int) {
+	maxBytes, maxFiles := b.extractMaxBytes, b.extractMaxFiles
+	if maxBytes <= 0 {
+		maxBytes = defaultExtractMaxBytes
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultExtractMaxFiles
+	}
+	return maxBytes, maxFiles
+}
+
+func (b *RequestBuilder) untar(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	} else {
+		r = br
+	}
+
+	maxBytes, maxFiles := b.extractLimits()
+	tr := tar.NewReader(r)
+	for files := 0; ; files++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if files >= maxFiles {
+			return ErrArchiveTooLarge
+		}
+
+		path, err := safeJoin(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0755)
+		case tar.TypeReg:
+			if hdr.Size > maxBytes {
+				return ErrArchiveTooLarge
+			}
+			var n int64
+			n, err = writeFile(path, tr, hdr.FileInfo().Mode().Perm(), maxBytes)
+			maxBytes -= n
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *RequestBuilder) unzip(r io.Reader, dir string) error {
+	maxBytes, maxFiles := b.extractLimits()
+
+	tmp, err := ioutil.TempFile("", "httgo-unzip-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// the compressed size is bounded by the uncompressed limit as well
+	size, err := io.Copy(tmp, io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return err
+	}
+	if size > maxBytes {
+		return ErrArchiveTooLarge
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return err
+	}
+	if len(zr.File) > maxFiles {
+		return ErrArchiveTooLarge
+	}
+
+	for _, f := range zr.File {
+		path, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(path, 0755)
+		case mode.IsRegular():
+			var rc io.ReadCloser
+			rc, err = f.Open()
+			if err != nil {
+				return err
+			}
+			var n int64
+			n, err = writeFile(path, rc, mode.Perm(), maxBytes)
+			rc.Close()
+			maxBytes -= n
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// safeJoin joins an archive entry name to dir, rejecting names which escape it
+func safeJoin(dir, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return "", ErrUnsafePath
+	}
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrUnsafePath
+	}
+	return path, nil
+}
+
+// writeFile copies at most limit bytes of r into path
+func writeFile(path string, r io.Reader, perm os.FileMode, limit int64) (int64, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return 0, err
+	}
+	if perm == 0 {
+		perm = 0644
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > limit {
+		err = ErrArchiveTooLarge
+	}
+	return n, err
+}
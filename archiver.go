@@ -0,0 +1,294 @@
+package httgo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ArchiveRecord is the persisted trace of one attempt, including redirect hops
+type ArchiveRecord struct {
+	Time           time.Time
+	Duration       time.Duration
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	// BodyTruncated reports that a body was longer than ArchiveOptions.MaxBodySize
+	BodyTruncated bool
+	// Err is the transport error of failed attempts
+	Err string
+	// Tags carries retention and classification tags, see ArchiveOptions
+	Tags map[string]string
+}
+
+// ArchiveStore persists archive records, e.g. to a WORM bucket or an audit database
+type ArchiveStore interface {
+	Archive(rec *ArchiveRecord) error
+}
+
+// ArchiveOptions configures ArchiveResponses
+type ArchiveOptions struct {
+	// MaxBodySize bounds the archived part of each request and response body, defaults to 64KiB.
+	// A negative value archives metadata only.
+	MaxBodySize int
+	// Tags are attached to every record, e.g. {"retention": "7y"}
+	Tags map[string]string
+	// TagFunc adds per-record tags, it may inspect but must not modify the record
+	TagFunc func(rec *ArchiveRecord) map[string]string
+	// QueueSize is the number of records buffered for the store, defaults to 1024
+	QueueSize int
+	// OnError receives store errors and records dropped because the queue is full
+	OnError func(rec *ArchiveRecord, err error)
+}
+
+type archiver struct {
+	client *HTTPClient
+	store  ArchiveStore
+	opts   ArchiveOptions
+	queue  chan *ArchiveRecord
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+type archiveBody struct {
+	io.ReadCloser
+	a    *archiver
+	rec  *ArchiveRecord
+	buf  bytes.Buffer
+	done bool
+}
+
+const (
+	defaultArchiveMaxBodySize = 64 << 10
+	defaultArchiveQueueSize   = 1024
+)
+
+var (
+	// ErrArchiveQueueFull is reported to ArchiveOptions.OnError for dropped records
+	ErrArchiveQueueFull = errors.New("Archive Queue Full")
+
+	// ErrArchiveStopped is reported to ArchiveOptions.OnError for records of responses
+	// finished after StopArchiving
+	ErrArchiveStopped = errors.New("Archive Stopped")
+)
+
+// ArchiveResponses asynchronously persists the metadata and bounded bodies of every
+// request and response to store. Records are written by a background goroutine once
+// the response body is read to the end or closed; Authorization and cookie headers are masked,
+// as are the body fields of SetRedactionPaths. Calling it again replaces the store and options,
+// the records queued for the previous store are written first.
+func (c *HTTPClient) ArchiveResponses(store ArchiveStore, opts ArchiveOptions) *HTTPClient {
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = defaultArchiveMaxBodySize
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultArchiveQueueSize
+	}
+
+	a := &archiver{
+		client: c,
+		store:  store,
+		opts:   opts,
+		queue:  make(chan *ArchiveRecord, opts.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+
+	c.archiveMu.Lock()
+	old := c.archiver
+	c.archiver = a
+	c.archiveMu.Unlock()
+
+	if old != nil {
+		// the installed middleware picks up the new archiver
+		old.close()
+		return c
+	}
+	return c.Use(c.archiveMiddleware)
+}
+
+// StopArchiving stops ArchiveResponses, it returns once the queued records are written
+// and its background goroutine has exited
+func (c *HTTPClient) StopArchiving() *HTTPClient {
+	c.archiveMu.Lock()
+	a := c.archiver
+	c.archiver = nil
+	c.archiveMu.Unlock()
+
+	if a != nil {
+		a.close()
+	}
+	return c
+}
+
+func (c *HTTPClient) archiveMiddleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		c.archiveMu.Lock()
+		a := c.archiver
+		c.archiveMu.Unlock()
+
+		if a == nil {
+			return next.RoundTrip(req)
+		}
+		return a.roundTrip(req, next)
+	})
+}
+
+func (a *archiver) roundTrip(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	rec := &ArchiveRecord{
+		Time:          time.Now(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: maskHeader(req.Header),
+	}
+	if req.GetBody != nil && a.opts.MaxBodySize > 0 {
+		rc, err := req.GetBody()
+		if err == nil {
+			rec.RequestBody, _ = ioutil.ReadAll(io.LimitReader(rc, int64(a.opts.MaxBodySize)+1))
+			rc.Close()
+			if len(rec.RequestBody) > a.opts.MaxBodySize {
+				rec.RequestBody = rec.RequestBody[:a.opts.MaxBodySize]
+				rec.BodyTruncated = true
+			}
+			rec.RequestBody = a.client.redactBody(rec.RequestBody)
+		}
+	}
+
+	res, err := next.RoundTrip(req)
+	rec.Duration = time.Since(rec.Time)
+	if err != nil {
+		rec.Err = err.Error()
+		a.enqueue(rec)
+		return res, err
+	}
+
+	rec.StatusCode = res.StatusCode
+	rec.ResponseHeader = maskHeader(res.Header)
+	if res.Body == nil || res.StatusCode == http.StatusSwitchingProtocols || a.opts.MaxBodySize < 0 {
+		a.enqueue(rec)
+		return res, nil
+	}
+
+	res.Body = &archiveBody{
+		ReadCloser: res.Body,
+		a:          a,
+		rec:        rec,
+	}
+	return res, nil
+}
+
+func (a *archiver) enqueue(rec *ArchiveRecord) {
+	tags := make(map[string]string, len(a.opts.Tags))
+	for k, v := range a.opts.Tags {
+		tags[k] = v
+	}
+	if a.opts.TagFunc != nil {
+		for k, v := range a.opts.TagFunc(rec) {
+			tags[k] = v
+		}
+	}
+	rec.Tags = tags
+
+	select {
+	case <-a.stop:
+		if a.opts.OnError != nil {
+			a.opts.OnError(rec, ErrArchiveStopped)
+		}
+		return
+	default:
+	}
+
+	select {
+	case a.queue <- rec:
+	default:
+		if a.opts.OnError != nil {
+			a.opts.OnError(rec, ErrArchiveQueueFull)
+		}
+	}
+}
+
+func (a *archiver) run() {
+	defer close(a.done)
+	for {
+		select {
+		case rec := <-a.queue:
+			a.archive(rec)
+		case <-a.stop:
+			for {
+				select {
+				case rec := <-a.queue:
+					a.archive(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *archiver) archive(rec *ArchiveRecord) {
+	err := a.store.Archive(rec)
+	if err != nil && a.opts.OnError != nil {
+		a.opts.OnError(rec, err)
+	}
+}
+
+// close stops the background goroutine once the queue is drained
+func (a *archiver) close() {
+	close(a.stop)
+	<-a.done
+}
+
+func (ab *archiveBody) Read(p []byte) (int, error) {
+	n, err := ab.ReadCloser.Read(p)
+	if rest := ab.a.opts.MaxBodySize + 1 - ab.buf.Len(); rest > 0 {
+		if rest > n {
+			rest = n
+		}
+		ab.buf.Write(p[:rest])
+	}
+	if err == io.EOF {
+		ab.finish()
+	}
+	return n, err
+}
+
+func (ab *archiveBody) Close() error {
+	ab.finish()
+	return ab.ReadCloser.Close()
+}
+
+func (ab *archiveBody) finish() {
+	if ab.done {
+		return
+	}
+	ab.done = true
+	body := ab.buf.Bytes()
+	if len(body) > ab.a.opts.MaxBodySize {
+		body = body[:ab.a.opts.MaxBodySize]
+		ab.rec.BodyTruncated = true
+	}
+	ab.rec.ResponseBody = ab.a.client.redactBody(body)
+	ab.a.enqueue(ab.rec)
+}
+
+// maskHeader copies h, masking credentials and cookies
+func maskHeader(h http.Header) http.Header {
+	m := make(http.Header, len(h))
+	for k, v := range h {
+		switch k {
+		case "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie":
+			v = []string{"***"}
+		}
+		m[k] = append([]string(nil), v...)
+	}
+	return m
+}
@@ -0,0 +1,94 @@
+package httgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryArchive struct {
+	mu   sync.Mutex
+	recs []*ArchiveRecord
+}
+
+func (m *memoryArchive) Archive(rec *ArchiveRecord) error {
+	m.mu.Lock()
+	m.recs = append(m.recs, rec)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memoryArchive) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.recs)
+}
+
+func TestArchiveResponsesLifecycle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	before := runtime.NumGoroutine()
+	first, second := new(memoryArchive), new(memoryArchive)
+	c := New()
+	for i := 0; i < 5; i++ {
+		c.ArchiveResponses(first, ArchiveOptions{})
+	}
+	c.Get(srv.URL).Do().Close()
+	c.ArchiveResponses(second, ArchiveOptions{})
+	c.Get(srv.URL).Do().Close()
+	c.StopArchiving()
+	c.Get(srv.URL).Do().Close()
+
+	if first.len() != 1 || second.len() != 1 {
+		t.Fatalf("archived %d and %d records, want 1 and 1", first.len(), second.len())
+	}
+
+	c.ArchiveResponses(first, ArchiveOptions{})
+	c.HardReset()
+	c.CloseIdleConnections()
+	for i := 0; ; i++ {
+		n := runtime.NumGoroutine()
+		if n <= before {
+			break
+		}
+		if i == 100 {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left over, %d before:\n%s", n, before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestArchiveMasksCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+	}))
+	defer srv.Close()
+
+	store := new(memoryArchive)
+	c := New().ArchiveResponses(store, ArchiveOptions{})
+	c.Get(srv.URL).
+		SetHeader("Authorization", []string{"Bearer token-secret"}).
+		SetHeader("Cookie", []string{"session=client-secret"}).
+		Do().Close()
+	c.StopArchiving()
+
+	if store.len() != 1 {
+		t.Fatalf("archived %d records, want 1", store.len())
+	}
+	rec := store.recs[0]
+	for _, h := range []http.Header{rec.RequestHeader, rec.ResponseHeader} {
+		for k, vs := range h {
+			for _, v := range vs {
+				if strings.Contains(v, "secret") {
+					t.Errorf("archived %s header leaks %q", k, v)
+				}
+			}
+		}
+	}
+}
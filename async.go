@@ -0,0 +1,91 @@
+package httgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Future is the pending result of DecodeAsync
+type Future struct {
+	done chan struct{}
+	errs []error
+}
+
+var (
+	decodeOnce sync.Once
+	decodeJobs chan func()
+)
+
+// DecodeAsync reads the response body and decodes it into v on a shared worker pool,
+// so CPU-bound decoding overlaps with the network reads of subsequent requests.
+// The body is decoded as XML when the Content-Type says so and as JSON otherwise.
+func (b *RequestBuilder) DecodeAsync(v interface{}) *Future {
+	f := &Future{
+		done: make(chan struct{}),
+	}
+
+	res := b.response()
+	if res == nil || !b.consume("DecodeAsync", false) {
+		f.errs = b.errs
+		close(f.done)
+		return f
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+		f.errs = b.errs
+		close(f.done)
+		return f
+	}
+
+	c := b.client
+	errs := append([]error(nil), b.errs...)
+	isXML := strings.Contains(res.Header.Get("Content-Type"), "xml")
+
+	submitDecode(func() {
+		var err error
+		if isXML {
+			err = c.decodeXML(bytes.NewReader(data), v)
+		} else {
+			err = c.decodeJSON(bytes.NewReader(data), v)
+		}
+		if err != nil {
+			errs = append(errs, wrapPhase(ErrPhaseDecode, err))
+		}
+		f.errs = errs
+		close(f.done)
+	})
+
+	return f
+}
+
+// Done is closed once decoding has finished
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until decoding has finished and returns the request errors
+func (f *Future) Wait() []error {
+	<-f.done
+	return f.errs
+}
+
+func submitDecode(job func()) {
+	decodeOnce.Do(func() {
+		n := runtime.GOMAXPROCS(0)
+		decodeJobs = make(chan func(), n*4)
+		for i := 0; i < n; i++ {
+			go func() {
+				for job := range decodeJobs {
+					job()
+				}
+			}()
+		}
+	})
+	decodeJobs <- job
+}
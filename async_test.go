@@ -0,0 +1,26 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeAsyncConsumesBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"a":1}`))
+	}))
+	defer srv.Close()
+
+	b := New().EnableStrictMode().Get(srv.URL)
+	var v map[string]int
+	if errs := b.DecodeAsync(&v).Wait(); len(errs) != 0 || v["a"] != 1 {
+		t.Fatalf("decoded %v, errors %v", v, errs)
+	}
+	if errs := b.DecodeAsync(&v).Wait(); len(errs) == 0 || !errors.Is(errs[len(errs)-1], ErrMisuse) {
+		t.Fatalf("second DecodeAsync: want ErrMisuse, got %v", errs)
+	}
+	b.Close()
+}
@@ -0,0 +1,59 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Attempt identifies a try of a request, Max is 0 when the number of tries is unbounded
+type Attempt struct {
+	Number int
+	Max    int
+}
+
+// HeaderXAttempt carries the Attempt of each try as "number/max" when EnableAttemptHeader is set
+const HeaderXAttempt = "X-Attempt"
+
+// WithAttempt annotates ctx with the attempt number of a retry loop,
+// retries made by the client itself are annotated automatically
+func WithAttempt(ctx context.Context, number, max int) context.Context {
+	return context.WithValue(ctx, attemptKey, Attempt{
+		Number: number,
+		Max:    max,
+	})
+}
+
+// AttemptFromContext returns the attempt a request belongs to, for use in middlewares.
+// Requests sent once without retries report Attempt{1, 1} and false.
+func AttemptFromContext(ctx context.Context) (Attempt, bool) {
+	a, ok := ctx.Value(attemptKey).(Attempt)
+	if !ok {
+		return Attempt{
+			Number: 1,
+			Max:    1,
+		}, false
+	}
+	return a, true
+}
+
+// EnableAttemptHeader sends X-Attempt: number/max with every request, so cooperating
+// services can tell retries apart, e.g. to skip expensive work already done
+func (c *HTTPClient) EnableAttemptHeader() *HTTPClient {
+	c.attemptHeader = true
+	return c
+}
+
+func (a Attempt) String() string {
+	if a.Max <= 0 {
+		return strconv.Itoa(a.Number)
+	}
+	return strconv.Itoa(a.Number) + "/" + strconv.Itoa(a.Max)
+}
+
+func setAttemptHeader(req *http.Request) *http.Request {
+	a, _ := AttemptFromContext(req.Context())
+	req = req.Clone(req.Context())
+	req.Header.Set(HeaderXAttempt, a.String())
+	return req
+}
@@ -0,0 +1,216 @@
+package httgo
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// TokenFunc returns the token type (e.g. "Bearer") and the access token to send
+type TokenFunc func() (tokenType, token string, err error)
+
+type digestAuth struct {
+	user string
+	pass string
+}
+
+var (
+	// ErrUnsupportedDigest is returned for Digest challenges using an unknown algorithm
+	ErrUnsupportedDigest = errors.New("Unsupported Digest Algorithm")
+)
+
+// SetBearerToken sends "Authorization: Bearer tok"
+func (b *RequestBuilder) SetBearerToken(tok string) *RequestBuilder {
+	b.unsent("SetBearerToken")
+	b.header.Set("Authorization", "Bearer "+tok)
+	return b
+}
+
+// SetDigestAuth answers a Digest 401 challenge with user and pass and resends the request once.
+// The request body must be replayable, which is the case for SetBody* helpers.
+func (b *RequestBuilder) SetDigestAuth(user, pass string) *RequestBuilder {
+	b.digest = &digestAuth{
+		user: user,
+		pass: pass,
+	}
+	return b
+}
+
+// SetTokenSource calls fn before every request without an Authorization header
+// and sends the returned token, fn is expected to cache and refresh tokens itself
+func (c *HTTPClient) SetTokenSource(fn TokenFunc) *HTTPClient {
+	c.tokenSource = fn
+	return c
+}
+
+func (b *RequestBuilder) applyToken() error {
+	fn := b.client.tokenSource
+	if fn == nil || b.req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	typ, tok, err := fn()
+	if err != nil {
+		return err
+	}
+	if typ == "" {
+		typ = "Bearer"
+	}
+	b.req.Header.Set("Authorization", typ+" "+tok)
+	return nil
+}
+
+// retryDigest resends the request with digest credentials when res is a Digest challenge
+func (b *RequestBuilder) retryDigest(res *http.Response) (*http.Response, error) {
+	scheme, params := ParseAuthChallenge(res.Header.Get("WWW-Authenticate"))
+	if !strings.EqualFold(scheme, "digest") {
+		return res, nil
+	}
+	if b.req.Body != nil && b.req.Body != http.NoBody && b.req.GetBody == nil {
+		return res, nil
+	}
+
+	auth, err := b.digest.authorization(b.req, params)
+	if err != nil {
+		return res, err
+	}
+
+	req := b.req.Clone(b.req.Context())
+	if b.req.GetBody != nil {
+		req.Body, err = b.req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", auth)
+
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	b.req = req
+	return b.client.client.Do(req)
+}
+
+func (d *digestAuth) authorization(req *http.Request, params map[string]string) (string, error) {
+	var h func() hash.Hash
+	algo := params["algorithm"]
+	switch strings.ToUpper(strings.TrimSuffix(strings.ToUpper(algo), "-SESS")) {
+	case "", "MD5":
+		h = md5.New
+	case "SHA-256":
+		h = sha256.New
+	default:
+		return "", ErrUnsupportedDigest
+	}
+	sum := func(s string) string {
+		hh := h()
+		io.WriteString(hh, s)
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	cb := make([]byte, 8)
+	_, err := io.ReadFull(rand.Reader, cb)
+	if err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cb)
+	nonce := params["nonce"]
+	realm := params["realm"]
+	uri := req.URL.RequestURI()
+	nc := "00000001"
+
+	ha1 := sum(d.user + ":" + realm + ":" + d.pass)
+	if strings.HasSuffix(strings.ToUpper(algo), "-SESS") {
+		ha1 = sum(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := sum(req.Method + ":" + uri)
+
+	qop := ""
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	var response string
+	if qop == "" {
+		response = sum(ha1 + ":" + nonce + ":" + ha2)
+	} else {
+		response = sum(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	parts := []string{
+		`username="` + d.user + `"`,
+		`realm="` + realm + `"`,
+		`nonce="` + nonce + `"`,
+		`uri="` + uri + `"`,
+		`response="` + response + `"`,
+	}
+	if algo != "" {
+		parts = append(parts, "algorithm="+algo)
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, `cnonce="`+cnonce+`"`)
+	}
+	if opaque, ok := params["opaque"]; ok {
+		parts = append(parts, `opaque="`+opaque+`"`)
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+// ParseAuthChallenge splits a WWW-Authenticate challenge such as
+// `Bearer realm="https://auth.example.com/token",service="registry"` into its scheme and parameters
+func ParseAuthChallenge(chal string) (scheme string, params map[string]string) {
+	chal = strings.TrimSpace(chal)
+	sp := strings.IndexByte(chal, ' ')
+	if sp < 0 {
+		return chal, make(map[string]string)
+	}
+	return chal[:sp], parseAuthParams(chal[sp+1:])
+}
+
+// parseAuthParams parses the comma separated key=value or key="value" list of a challenge
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) != 0 {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " ")
+
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			var sb strings.Builder
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				sb.WriteByte(s[i])
+			}
+			val = sb.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			val = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = val
+	}
+	return params
+}
@@ -0,0 +1,260 @@
+package httgo
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BalanceStrategy selects the host of each request sent to a host pool, see SetHosts
+type BalanceStrategy int
+
+const (
+	// RoundRobin spreads requests evenly over the healthy hosts
+	RoundRobin BalanceStrategy = iota
+	// Weighted spreads requests over the healthy hosts in proportion to their SetHostWeight
+	Weighted
+	// Failover sends every request to the first healthy host in the order given
+	Failover
+)
+
+const (
+	defaultHostMaxFailures = 3
+	defaultHostCooldown    = 30 * time.Second
+)
+
+// hostPool balances requests over the base URLs of SetHosts and
+// ejects hosts failing maxFailures times in a row for cooldown
+type hostPool struct {
+	strategy    BalanceStrategy
+	maxFailures int
+	cooldown    time.Duration
+
+	mu    sync.Mutex
+	hosts []*poolHost
+	next  int
+}
+
+type poolHost struct {
+	url       *url.URL
+	weight    int
+	current   int
+	failures  int
+	downUntil time.Time
+}
+
+// SetHosts sends relative request URLs, and URLs on any of the hosts, to a pool of base URLs
+// such as "https://replica-1:8443/v1". A host failing 3 times in a row is skipped for 30 seconds,
+// see SetHostEjection, and replayable requests failing on one host move on to the next one.
+// An empty list disables the pool.
+func (c *HTTPClient) SetHosts(hosts []string, strategy BalanceStrategy) *HTTPClient {
+	if len(hosts) == 0 {
+		c.hostPool = nil
+		return c
+	}
+
+	p := &hostPool{
+		strategy:    strategy,
+		maxFailures: defaultHostMaxFailures,
+		cooldown:    defaultHostCooldown,
+	}
+	if c.hostPool != nil {
+		p.maxFailures = c.hostPool.maxFailures
+		p.cooldown = c.hostPool.cooldown
+	}
+	for _, h := range hosts {
+		u, err := checkURL(strings.TrimSuffix(h, "/"))
+		if err != nil {
+			c.fail(ErrPhaseBuild, err)
+			return c
+		}
+		p.hosts = append(p.hosts, &poolHost{
+			url:    u,
+			weight: 1,
+		})
+	}
+	c.hostPool = p
+	c.baseURL = p.hosts[0].url.String()
+	return c
+}
+
+// SetHostWeight sets the share of requests host, one of the SetHosts URLs, receives
+// with the Weighted strategy, 1 by default
+func (c *HTTPClient) SetHostWeight(host string, weight int) *HTTPClient {
+	if c.hostPool == nil || weight <= 0 {
+		return c
+	}
+	host = strings.TrimSuffix(host, "/")
+	c.hostPool.mu.Lock()
+	defer c.hostPool.mu.Unlock()
+	for _, h := range c.hostPool.hosts {
+		if h.url.String() == host || h.url.Host == host {
+			h.weight = weight
+		}
+	}
+	return c
+}
+
+// SetHostEjection skips pool hosts for cooldown after maxFailures consecutive transport errors
+// or 5xx responses, a single failure ejects them again once they are back
+func (c *HTTPClient) SetHostEjection(maxFailures int, cooldown time.Duration) *HTTPClient {
+	if c.hostPool == nil {
+		return c
+	}
+	if maxFailures > 0 {
+		c.hostPool.maxFailures = maxFailures
+	}
+	if cooldown > 0 {
+		c.hostPool.cooldown = cooldown
+	}
+	return c
+}
+
+// roundTrip sends req to the hosts of the pool when it targets one of them, otherwise with next as is
+func (p *hostPool) roundTrip(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	member := p.member(req.URL)
+	if member == nil {
+		return next(req)
+	}
+
+	ctx := req.Context()
+	tried := make(map[*poolHost]bool, len(p.hosts))
+	for {
+		h := p.pick(tried)
+		tried[h] = true
+
+		areq, err := p.rewrite(req, member, h, len(tried) > 1)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := next(areq)
+		if ctx.Err() != nil {
+			return res, err
+		}
+		if !errors.Is(err, ErrCircuitOpen) {
+			p.done(h, isFailure(res, err))
+		}
+		moveOn := retryableAttempt(res, err) || errors.Is(err, ErrCircuitOpen)
+		if !moveOn || len(tried) == len(p.hosts) || !replayable(req) {
+			return res, err
+		}
+		if res != nil {
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+			res.Body.Close()
+		}
+	}
+}
+
+// member returns the pool host u belongs to, nil for other URLs
+func (p *hostPool) member(u *url.URL) *poolHost {
+	for _, h := range p.hosts {
+		if u.Scheme == h.url.Scheme && u.Host == h.url.Host && underPath(u.Path, h.url.Path) {
+			return h
+		}
+	}
+	return nil
+}
+
+// underPath reports whether path is base or below it, /api covers /api/v1 but not /apiv2
+func underPath(path, base string) bool {
+	if !strings.HasPrefix(path, base) {
+		return false
+	}
+	return len(path) == len(base) || strings.HasSuffix(base, "/") || path[len(base)] == '/'
+}
+
+// pick returns the next host not tried yet, healthy ones first
+func (p *hostPool) pick(tried map[*poolHost]bool) *poolHost {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy []*poolHost
+	var fallback *poolHost
+	for _, h := range p.hosts {
+		if tried[h] {
+			continue
+		}
+		if now.After(h.downUntil) {
+			healthy = append(healthy, h)
+		} else if fallback == nil || h.downUntil.Before(fallback.downUntil) {
+			fallback = h
+		}
+	}
+	if len(healthy) == 0 {
+		// every remaining host is ejected, the one back soonest is better than failing
+		return fallback
+	}
+
+	switch p.strategy {
+	case Failover:
+		return healthy[0]
+	case Weighted:
+		// smooth weighted round robin
+		var best *poolHost
+		total := 0
+		for _, h := range healthy {
+			h.current += h.weight
+			total += h.weight
+			if best == nil || h.current > best.current {
+				best = h
+			}
+		}
+		best.current -= total
+		return best
+	}
+
+	for i := range p.hosts {
+		h := p.hosts[(p.next+i)%len(p.hosts)]
+		if !tried[h] && now.After(h.downUntil) {
+			p.next = (p.next + i + 1) % len(p.hosts)
+			return h
+		}
+	}
+	return healthy[0]
+}
+
+// done records the outcome of an attempt on h
+func (p *hostPool) done(h *poolHost, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !failed {
+		h.failures = 0
+		h.downUntil = time.Time{}
+		return
+	}
+	h.failures++
+	if h.failures >= p.maxFailures {
+		h.downUntil = time.Now().Add(p.cooldown)
+	}
+}
+
+// rewrite moves req from the pool host member to h, with a fresh body when it was sent before
+func (p *hostPool) rewrite(req *http.Request, member, h *poolHost, resend bool) (*http.Request, error) {
+	if h == member && !resend {
+		return req, nil
+	}
+
+	areq := req.Clone(req.Context())
+	if resend && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		areq.Body = body
+	}
+	if req.Host == "" || req.Host == req.URL.Host {
+		areq.Host = h.url.Host
+	}
+	areq.URL.Scheme = h.url.Scheme
+	areq.URL.Host = h.url.Host
+	areq.URL.Path = h.url.Path + strings.TrimPrefix(req.URL.Path, member.url.Path)
+	areq.URL.RawPath = ""
+	return areq, nil
+}
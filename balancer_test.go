@@ -0,0 +1,28 @@
+package httgo
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHostPoolMember(t *testing.T) {
+	c := New().SetHosts([]string{"http://a.example.com/api", "http://b.example.com/"}, RoundRobin)
+
+	tests := []struct {
+		url    string
+		member bool
+	}{
+		{"http://a.example.com/api", true},
+		{"http://a.example.com/api/v1/users", true},
+		{"http://a.example.com/apiv2/users", false},
+		{"http://a.example.com/other", false},
+		{"http://b.example.com/anything", true},
+		{"https://a.example.com/api", false},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := c.hostPool.member(u) != nil; got != tt.member {
+			t.Errorf("member(%s) = %v, want %v", tt.url, got, tt.member)
+		}
+	}
+}
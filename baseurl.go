@@ -0,0 +1,37 @@
+package httgo
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetBaseURL sets the URL relative request URLs are appended to, e.g. "https://api.example.com/v1"
+func (c *HTTPClient) SetBaseURL(u string) *HTTPClient {
+	c.baseURL = strings.TrimSuffix(u, "/")
+	return c
+}
+
+// SetPathParam replaces "{key}" in the request URL with the path-escaped value
+func (b *RequestBuilder) SetPathParam(key, value string) *RequestBuilder {
+	if b.pathParams == nil {
+		b.pathParams = make(map[string]string)
+	}
+	b.pathParams[key] = value
+	return b
+}
+
+// SetPathParams is SetPathParam for several params
+func (b *RequestBuilder) SetPathParams(params map[string]string) *RequestBuilder {
+	for k, v := range params {
+		b.SetPathParam(k, v)
+	}
+	return b
+}
+
+func (b *RequestBuilder) expandURL() string {
+	u := b.url
+	for k, v := range b.pathParams {
+		u = strings.Replace(u, "{"+k+"}", url.PathEscape(v), -1)
+	}
+	return joinURL(b.client.baseURL, u)
+}
@@ -0,0 +1,40 @@
+package httgo
+
+import (
+	"context"
+)
+
+// Batch executes many prepared requests concurrently
+type Batch struct {
+	builders []*RequestBuilder
+}
+
+// NewBatch returns an empty Batch
+func NewBatch() *Batch {
+	return new(Batch)
+}
+
+// Add appends a prepared request, it must not have been sent yet
+func (bt *Batch) Add(b *RequestBuilder) *Batch {
+	bt.builders = append(bt.builders, b)
+	return bt
+}
+
+// DoAll sends every request with at most concurrency in flight and returns
+// the builders in the order they were added, ready for JSON, String and so on.
+// Requests not started before ctx is done fail with ctx.Err().
+func (bt *Batch) DoAll(ctx context.Context, concurrency int) []*RequestBuilder {
+	if len(bt.builders) == 0 {
+		return bt.builders
+	}
+
+	g := bt.builders[0].client.Group(ctx).
+		SetLimit(concurrency).
+		SetFatal(nil)
+	for _, b := range bt.builders {
+		g.Go(b)
+	}
+	g.Wait()
+
+	return bt.builders
+}
@@ -0,0 +1,39 @@
+package httgo
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds SetMaxResponseBodySize
+var ErrResponseTooLarge = errors.New("Response Body Too Large")
+
+// limitBody fails reads once more than remaining bytes were produced
+type limitBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// SetMaxResponseBodySize bounds every response body to n bytes after decompression,
+// reads past the limit fail with ErrResponseTooLarge. Zero disables the limit.
+func (c *HTTPClient) SetMaxResponseBodySize(n int64) *HTTPClient {
+	c.maxResponseBodySize = n
+	return c
+}
+
+func (l *limitBody) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		n, err := l.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
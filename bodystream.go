@@ -0,0 +1,111 @@
+package httgo
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const defaultExpectContinueTimeout = time.Second
+
+// rewindBody seeks back to the start of a streamed body before its first read, so replays start over
+type rewindBody struct {
+	r       io.Reader
+	seeker  io.Seeker
+	start   int64
+	rewound bool
+}
+
+// SetBodyStream sends r as the body without buffering it. contentLength is the number of bytes r yields,
+// a negative one sends the body chunked. Bodies implementing io.ReaderAt or io.Seeker, such as *os.File,
+// are replayed on redirects and retries, others are sent only once. r is not closed.
+func (b *RequestBuilder) SetBodyStream(r io.Reader, contentLength int64) *RequestBuilder {
+	b.body = r
+	b.bodyLength = contentLength
+	b.bodyStream = true
+	return b
+}
+
+// EnableExpectContinue sends "Expect: 100-continue" with request bodies and waits up to timeout,
+// 1 second when not positive, for the server to accept them, so rejected uploads are not transmitted
+func (c *HTTPClient) EnableExpectContinue(timeout time.Duration) *HTTPClient {
+	if timeout <= 0 {
+		timeout = defaultExpectContinueTimeout
+	}
+	c.expectContinue = true
+	c.transport.ExpectContinueTimeout = timeout
+	return c
+}
+
+// streamBody sets the length and the replay of a body from SetBodyStream
+func (b *RequestBuilder) streamBody() {
+	req := b.req
+	if b.body == nil {
+		return
+	}
+	if b.bodyLength == 0 {
+		req.ContentLength = 0
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) {
+			return http.NoBody, nil
+		}
+		return
+	}
+
+	req.ContentLength = b.bodyLength
+	if b.bodyLength < 0 {
+		req.ContentLength = -1
+	}
+
+	seeker, ok := b.body.(io.Seeker)
+	var start int64
+	if ok {
+		var err error
+		start, err = seeker.Seek(0, io.SeekCurrent)
+		ok = err == nil
+	}
+	ra, isReaderAt := b.body.(io.ReaderAt)
+
+	switch {
+	case isReaderAt && b.bodyLength > 0:
+		length := b.bodyLength
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(io.NewSectionReader(ra, start, length)), nil
+		}
+	case ok:
+		r := b.body
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(&rewindBody{
+				r:      r,
+				seeker: seeker,
+				start:  start,
+			}), nil
+		}
+	default:
+		req.GetBody = nil
+		req.Body = ioutil.NopCloser(b.body)
+		return
+	}
+	req.Body, _ = req.GetBody()
+}
+
+// expectContinue asks the server to accept the body first when EnableExpectContinue is set
+func (b *RequestBuilder) expectContinue() {
+	req := b.req
+	if !b.client.expectContinue || req.Body == nil || req.Body == http.NoBody || req.Header.Get("Expect") != "" {
+		return
+	}
+	req.Header.Set("Expect", "100-continue")
+}
+
+func (rb *rewindBody) Read(p []byte) (int, error) {
+	if !rb.rewound {
+		rb.rewound = true
+		_, err := rb.seeker.Seek(rb.start, io.SeekStart)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return rb.r.Read(p)
+}
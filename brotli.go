@@ -0,0 +1,17 @@
+//go:build brotli
+// +build brotli
+
+package httgo
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// br decoding is only available when building with the brotli tag
+func init() {
+	contentDecoders["br"] = func(r io.Reader) (io.Reader, error) {
+		return brotli.NewReader(r), nil
+	}
+}
@@ -0,0 +1,211 @@
+package httgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kpango/gache"
+)
+
+// CacheStore is the storage backend used for response caching.
+// Values are serialized HTTP responses, so any store that can keep
+// bytes (memory, disk, Redis...) can be shared across processes.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, val []byte) error
+	Delete(key string) error
+	Clear() error
+}
+
+// Cache status values reported by CacheStatus, GetTrace and the X-Cache header
+const (
+	CacheStatusHit  = "HIT"
+	CacheStatusMiss = "MISS"
+	// CacheStatusStale is reported for stale responses served while their origin fails, see SetCacheStaleIfError
+	CacheStatusStale = "STALE"
+
+	HeaderXCache = "X-Cache"
+)
+
+type memoryStore struct {
+	g gache.Gache
+}
+
+type fileStore struct {
+	dir string
+}
+
+// NewMemoryStore returns the default in-memory CacheStore backed by gache.
+// Each store has its own entries, clients enabling the cache do not share responses.
+func NewMemoryStore() CacheStore {
+	return memoryStore{
+		g: gache.New(),
+	}
+}
+
+func (m memoryStore) Get(key string) ([]byte, bool) {
+	val, ok := m.g.Get(key)
+	if !ok {
+		return nil, false
+	}
+	b, ok := val.([]byte)
+	return b, ok
+}
+
+func (m memoryStore) Set(key string, val []byte) error {
+	m.g.Set(key, val)
+	return nil
+}
+
+func (m memoryStore) Delete(key string) error {
+	m.g.Delete(key)
+	return nil
+}
+
+func (m memoryStore) Clear() error {
+	m.g.Clear()
+	return nil
+}
+
+// NewFileStore returns a CacheStore persisting responses as files under dir
+func NewFileStore(dir string) (CacheStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &fileStore{
+		dir: dir,
+	}, nil
+}
+
+func (f *fileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:]))
+}
+
+func (f *fileStore) Get(key string) ([]byte, bool) {
+	b, err := ioutil.ReadFile(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func (f *fileStore) Set(key string, val []byte) error {
+	tmp, err := ioutil.TempFile(f.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(val)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+func (f *fileStore) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (f *fileStore) Clear() error {
+	files, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		err = os.Remove(filepath.Join(f.dir, file.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnableCacheStatusHeader adds a synthetic X-Cache: HIT/MISS header to responses when caching is enabled
+func (c *HTTPClient) EnableCacheStatusHeader() *HTTPClient {
+	c.cacheStatusHeader = true
+	return c
+}
+
+// CacheStatus returns CacheStatusHit, CacheStatusMiss or CacheStatusStale, or "" when caching was not involved.
+// Concurrent misses of the same GET or HEAD send a single request, the others report a hit.
+func (b *RequestBuilder) CacheStatus() string {
+	return b.cacheStatus
+}
+
+// cacheableStatus lists the status codes cacheable by default, see RFC 9110 section 15.1
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// cacheableRequest reports whether req may be answered from the cache and its response stored:
+// a GET or HEAD without Cache-Control: no-store
+func cacheableRequest(req *http.Request) bool {
+	return coalescable(req) && !hasCacheDirective(req.Header, "no-store")
+}
+
+// cacheableResponse reports whether res may be stored: a cacheable status without
+// Cache-Control no-store or private, the store may be shared across processes
+func cacheableResponse(res *http.Response) bool {
+	return cacheableStatus[res.StatusCode] &&
+		!hasCacheDirective(res.Header, "no-store") &&
+		!hasCacheDirective(res.Header, "private")
+}
+
+func hasCacheDirective(h http.Header, directive string) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if i := strings.IndexByte(d, '='); i >= 0 {
+				d = d[:i]
+			}
+			if strings.EqualFold(d, directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cacheStatusFromContext(ctx context.Context) string {
+	st, _ := ctx.Value(cacheStatusKey).(string)
+	return st
+}
+
+func encodeResponse(res *http.Response) ([]byte, error) {
+	return httputil.DumpResponse(res, true)
+}
+
+func decodeResponse(b []byte, req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+}
@@ -0,0 +1,107 @@
+package httgo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sync"
+)
+
+// dedupStore is an in-memory CacheStore keeping a single copy of identical bodies
+type dedupStore struct {
+	mu      sync.RWMutex
+	entries map[string]dedupEntry
+	bodies  map[[sha256.Size]byte]*dedupBody
+}
+
+type dedupEntry struct {
+	head []byte
+	sum  [sha256.Size]byte
+}
+
+type dedupBody struct {
+	data []byte
+	refs int
+}
+
+var headerEnd = []byte("\r\n\r\n")
+
+// NewDedupStore returns an in-memory CacheStore which stores identical response
+// bodies only once, keyed by their SHA-256, which saves memory in large crawls
+func NewDedupStore() CacheStore {
+	return &dedupStore{
+		entries: make(map[string]dedupEntry),
+		bodies:  make(map[[sha256.Size]byte]*dedupBody),
+	}
+}
+
+// EnableCacheDedup enables caching backed by NewDedupStore
+func (c *HTTPClient) EnableCacheDedup() *HTTPClient {
+	return c.SetCacheStore(NewDedupStore())
+}
+
+func (d *dedupStore) Get(key string) ([]byte, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	e, ok := d.entries[key]
+	if !ok {
+		return nil, false
+	}
+	body := d.bodies[e.sum]
+	val := make([]byte, 0, len(e.head)+len(body.data))
+	val = append(val, e.head...)
+	return append(val, body.data...), true
+}
+
+func (d *dedupStore) Set(key string, val []byte) error {
+	head, body := val, []byte(nil)
+	if i := bytes.Index(val, headerEnd); i >= 0 {
+		head, body = val[:i+len(headerEnd)], val[i+len(headerEnd):]
+	}
+	e := dedupEntry{
+		head: append([]byte(nil), head...),
+		sum:  sha256.Sum256(body),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.release(key)
+	b, ok := d.bodies[e.sum]
+	if !ok {
+		b = &dedupBody{
+			data: append([]byte(nil), body...),
+		}
+		d.bodies[e.sum] = b
+	}
+	b.refs++
+	d.entries[key] = e
+	return nil
+}
+
+func (d *dedupStore) Delete(key string) error {
+	d.mu.Lock()
+	d.release(key)
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *dedupStore) Clear() error {
+	d.mu.Lock()
+	d.entries = make(map[string]dedupEntry)
+	d.bodies = make(map[[sha256.Size]byte]*dedupBody)
+	d.mu.Unlock()
+	return nil
+}
+
+// release drops key and its body reference, d.mu must be held
+func (d *dedupStore) release(key string) {
+	e, ok := d.entries[key]
+	if !ok {
+		return
+	}
+	delete(d.entries, key)
+	b := d.bodies[e.sum]
+	b.refs--
+	if b.refs == 0 {
+		delete(d.bodies, e.sum)
+	}
+}
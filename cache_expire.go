@@ -0,0 +1,111 @@
+package httgo
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheExpiry expires cached responses after a fixed TTL and notifies the OnCacheExpire hook.
+// With a stale window expired responses are kept as stale for revalidation, see SetCacheStaleIfError.
+type cacheExpiry struct {
+	ttl        time.Duration
+	maxStale   time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+	mu         sync.Mutex
+	timers     map[string]*time.Timer
+	stale      map[string]*staleEntry
+	hook       func(key string)
+}
+
+// SetCacheTTL expires cached responses ttl after they were stored.
+// Expiry is tracked by this client, so entries persisted by an earlier process are not expired.
+func (c *HTTPClient) SetCacheTTL(ttl time.Duration) *HTTPClient {
+	c.cacheExpiry().ttl = ttl
+	return c
+}
+
+// OnCacheExpire calls fn with the cache key (method, URL and Fingerprint separated by spaces) of every response
+// expiring after SetCacheTTL, e.g. to refresh critical entries before the next request misses
+func (c *HTTPClient) OnCacheExpire(fn func(key string)) *HTTPClient {
+	c.cacheExpiry().hook = fn
+	return c
+}
+
+func (c *HTTPClient) cacheExpiry() *cacheExpiry {
+	if c.expiry == nil {
+		c.expiry = &cacheExpiry{
+			backoff:    defaultRevalidationBackoff,
+			maxBackoff: defaultMaxRevalidationBackoff,
+			timers:     make(map[string]*time.Timer),
+			stale:      make(map[string]*staleEntry),
+		}
+	}
+	return c.expiry
+}
+
+// schedule (re)starts the expiry timer of key, replacing the one of an earlier store
+func (e *cacheExpiry) schedule(store CacheStore, key string) {
+	if e == nil || e.ttl <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.stale, key)
+	e.after(e.ttl, key, func() {
+		if e.maxStale > 0 {
+			e.mu.Lock()
+			if _, rescheduled := e.timers[key]; !rescheduled {
+				e.stale[key] = new(staleEntry)
+				e.after(e.maxStale, key, func() {
+					e.mu.Lock()
+					delete(e.stale, key)
+					e.mu.Unlock()
+					store.Delete(key)
+				})
+			}
+			e.mu.Unlock()
+		} else {
+			store.Delete(key)
+		}
+		if e.hook != nil {
+			e.hook(key)
+		}
+	})
+}
+
+// after runs fn once d elapsed unless key is scheduled again meanwhile, e.mu must be held.
+// fn runs without e.mu held.
+func (e *cacheExpiry) after(d time.Duration, key string, fn func()) {
+	if t, ok := e.timers[key]; ok {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		e.mu.Lock()
+		if e.timers[key] != t {
+			e.mu.Unlock()
+			return
+		}
+		delete(e.timers, key)
+		e.mu.Unlock()
+		fn()
+	})
+	e.timers[key] = t
+}
+
+// reset stops every pending expiry without calling the hook
+func (e *cacheExpiry) reset() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key, t := range e.timers {
+		t.Stop()
+		delete(e.timers, key)
+	}
+	for key := range e.stale {
+		delete(e.stale, key)
+	}
+}
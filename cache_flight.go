@@ -0,0 +1,86 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// cacheFlights collapses concurrent cache misses of the same key into a single
+// upstream request whose response is shared with the others, as singleflight does
+type cacheFlights struct {
+	mu      sync.Mutex
+	flights map[string]*cacheFlight
+}
+
+// cacheFlight is the request in flight for a key, data stays nil when it could not be cached.
+// It is kept until the response is stored, so requests arriving meanwhile share it too.
+type cacheFlight struct {
+	done chan struct{}
+	data []byte
+	once sync.Once
+}
+
+func newCacheFlights() *cacheFlights {
+	return &cacheFlights{
+		flights: make(map[string]*cacheFlight),
+	}
+}
+
+// join returns the flight of key and whether the caller leads it, that is sends the request
+func (fs *cacheFlights) join(key string) (*cacheFlight, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if f, ok := fs.flights[key]; ok {
+		return f, false
+	}
+	f := &cacheFlight{
+		done: make(chan struct{}),
+	}
+	fs.flights[key] = f
+	return f, true
+}
+
+// release ends the flight of key when its leader had no response to share,
+// a shared response ends it once stored
+func (fs *cacheFlights) release(key string, f *cacheFlight) {
+	if f.publish(nil) {
+		fs.remove(key, f)
+	}
+}
+
+// remove forgets the flight of key, later callers lead a new one
+func (fs *cacheFlights) remove(key string, f *cacheFlight) {
+	fs.mu.Lock()
+	if fs.flights[key] == f {
+		delete(fs.flights, key)
+	}
+	fs.mu.Unlock()
+}
+
+// publish hands the serialized response to the waiters and reports whether it was the first call,
+// later ones are ignored
+func (f *cacheFlight) publish(data []byte) bool {
+	published := false
+	f.once.Do(func() {
+		f.data = data
+		close(f.done)
+		published = true
+	})
+	return published
+}
+
+// wait returns the response of the leader, false when it had none to share or ctx ended first
+func (f *cacheFlight) wait(ctx context.Context) ([]byte, bool) {
+	select {
+	case <-f.done:
+		return f.data, f.data != nil
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// coalescable reports requests whose responses can be shared between callers
+func coalescable(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
@@ -0,0 +1,139 @@
+package httgo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// mmapMinSize is the size from which mmapStore maps entries instead of reading them
+const mmapMinSize = 1 << 20
+
+// errNoMmap makes mmapStore read an entry instead of mapping it
+var errNoMmap = errors.New("Memory Map Unavailable")
+
+// mmapStore is a fileStore serving large entries from read-only memory maps
+type mmapStore struct {
+	*fileStore
+
+	mu      sync.Mutex
+	maps    map[string]*mapping
+	retired [][]byte
+}
+
+// mapping is the memory map of an entry file
+type mapping struct {
+	data []byte
+	info os.FileInfo
+}
+
+// NewMmapStore returns a CacheStore persisting responses as files under dir like NewFileStore,
+// serving entries of 1MB and more from read-only memory maps, so large cached bodies are paged in
+// from disk as they are read instead of being loaded into memory. An entry is mapped once for all its hits.
+// Maps of replaced or deleted entries stay valid, and keep their disk space, until the store is closed:
+// it implements io.Closer, close it once no cached response is read anymore.
+// Platforms without mmap read every entry like NewFileStore.
+func NewMmapStore(dir string) (CacheStore, error) {
+	fs, err := NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &mmapStore{
+		fileStore: fs.(*fileStore),
+		maps:      make(map[string]*mapping),
+	}, nil
+}
+
+func (m *mmapStore) Get(key string) ([]byte, bool) {
+	path := m.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mp, ok := m.maps[path]; ok {
+		if os.SameFile(mp.info, info) {
+			return mp.data, true
+		}
+		// replaced by another process sharing dir
+		m.retire(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	if info, err = f.Stat(); err != nil {
+		return nil, false
+	}
+	if info.Size() >= mmapMinSize {
+		// the map outlives the file descriptor
+		if data, err := mmapFile(f, info.Size()); err == nil {
+			m.maps[path] = &mapping{
+				data: data,
+				info: info,
+			}
+			return data, true
+		}
+	}
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func (m *mmapStore) Set(key string, val []byte) error {
+	err := m.fileStore.Set(key, val)
+	m.mu.Lock()
+	m.retire(m.path(key))
+	m.mu.Unlock()
+	return err
+}
+
+func (m *mmapStore) Delete(key string) error {
+	err := m.fileStore.Delete(key)
+	m.mu.Lock()
+	m.retire(m.path(key))
+	m.mu.Unlock()
+	return err
+}
+
+func (m *mmapStore) Clear() error {
+	err := m.fileStore.Clear()
+	m.mu.Lock()
+	for path := range m.maps {
+		m.retire(path)
+	}
+	m.mu.Unlock()
+	return err
+}
+
+// Close unmaps every entry, data returned by Get before must not be used anymore
+func (m *mmapStore) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for path := range m.maps {
+		m.retire(path)
+	}
+	var err error
+	for _, data := range m.retired {
+		if uerr := munmap(data); err == nil {
+			err = uerr
+		}
+	}
+	m.retired = nil
+	return err
+}
+
+// retire keeps the map of path for Close, hits served from it may still be read
+func (m *mmapStore) retire(path string) {
+	if mp, ok := m.maps[path]; ok {
+		m.retired = append(m.retired, mp.data)
+		delete(m.maps, path)
+	}
+}
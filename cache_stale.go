@@ -0,0 +1,148 @@
+package httgo
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRevalidationBackoff    = time.Second
+	defaultMaxRevalidationBackoff = 5 * time.Minute
+)
+
+// staleEntry tracks the failed revalidations of an expired response
+type staleEntry struct {
+	failures int
+	retryAt  time.Time
+}
+
+// SetCacheStaleIfError keeps responses expired by SetCacheTTL for maxStale more as stale.
+// A request for a stale response revalidates it with If-None-Match or If-Modified-Since.
+// When the origin fails with an error or a 5xx status the stale copy is served instead,
+// and the key is not revalidated again until an exponential backoff elapsed, see SetRevalidationBackoff.
+func (c *HTTPClient) SetCacheStaleIfError(maxStale time.Duration) *HTTPClient {
+	c.cacheExpiry().maxStale = maxStale
+	return c
+}
+
+// SetRevalidationBackoff sets the delay before revalidating a stale response again after its origin
+// failed, doubled on each further failure up to max. It is 1 second up to 5 minutes by default.
+func (c *HTTPClient) SetRevalidationBackoff(base, max time.Duration) *HTTPClient {
+	e := c.cacheExpiry()
+	if base > 0 {
+		e.backoff = base
+	}
+	if max > 0 {
+		e.maxBackoff = max
+	}
+	return c
+}
+
+// isStale reports whether key expired but is kept for revalidation
+func (e *cacheExpiry) isStale(key string) bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.stale[key]
+	return ok
+}
+
+// backingOff reports whether the revalidation of key waits after a failure of its origin
+func (e *cacheExpiry) backingOff(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st, ok := e.stale[key]
+	return ok && time.Now().Before(st.retryAt)
+}
+
+// revalidated records the outcome of a revalidation of key
+func (e *cacheExpiry) revalidated(key string, failed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st, ok := e.stale[key]
+	if !ok {
+		return
+	}
+	if !failed {
+		st.failures = 0
+		st.retryAt = time.Time{}
+		return
+	}
+	st.failures++
+	delay := e.backoff
+	for i := 1; i < st.failures && delay < e.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > e.maxBackoff {
+		delay = e.maxBackoff
+	}
+	st.retryAt = time.Now().Add(delay)
+}
+
+// revalidate turns the request for the stale response data into a conditional one
+func (b *RequestBuilder) revalidate(data []byte) {
+	sres, err := decodeResponse(data, b.req)
+	if err != nil {
+		return
+	}
+	b.stale = data
+	if etag := sres.Header.Get("ETag"); etag != "" && b.req.Header.Get("If-None-Match") == "" {
+		b.req.Header.Set("If-None-Match", etag)
+	}
+	if lm := sres.Header.Get("Last-Modified"); lm != "" && b.req.Header.Get("If-Modified-Since") == "" {
+		b.req.Header.Set("If-Modified-Since", lm)
+	}
+}
+
+// settleRevalidation serves the stale response when its origin failed, or answered
+// 304 Not Modified which makes it fresh again, and reports whether it did
+func (b *RequestBuilder) settleRevalidation(res *http.Response, err error, labels MetricsLabels) bool {
+	c := b.client
+	key := b.cacheKey
+	if b.req.Context().Err() != nil {
+		return false
+	}
+
+	status := CacheStatusStale
+	switch {
+	case err != nil || res.StatusCode >= 500:
+		c.expiry.revalidated(key, true)
+	case res.StatusCode == http.StatusNotModified:
+		c.expiry.revalidated(key, false)
+		c.expiry.schedule(c.cache, key)
+		status = CacheStatusHit
+	default:
+		c.expiry.revalidated(key, false)
+		return false
+	}
+
+	if res != nil {
+		io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+		res.Body.Close()
+	}
+	return b.serveCached(b.stale, status, labels)
+}
+
+// serveCached answers the request with the cached response data
+func (b *RequestBuilder) serveCached(data []byte, status string, labels MetricsLabels) bool {
+	c := b.client
+	cres, err := decodeResponse(data, b.req)
+	if err != nil {
+		return false
+	}
+	if c.metrics != nil {
+		c.metrics.CacheHit(labels)
+	}
+	b.cacheStatus = status
+	if c.cacheStatusHeader {
+		cres.Header.Set(HeaderXCache, status)
+	}
+	b.res = cres
+	b.cancel()
+	b.checkHTTPError(cres)
+	return true
+}
@@ -0,0 +1,168 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cached waits for the asynchronous store of the response to req
+func cached(t *testing.T, c *HTTPClient, method, url string) bool {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	key := c.cacheKey(req)
+	for i := 0; i < 50; i++ {
+		if _, ok := c.cache.Get(key); ok {
+			return true
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	return false
+}
+
+func TestCacheSafety(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		stored bool
+	}{
+		{"GET 200", http.MethodGet, "/", true},
+		{"POST", http.MethodPost, "/", false},
+		{"no-store", http.MethodGet, "/no-store", false},
+		{"private", http.MethodGet, "/private", false},
+		{"500", http.MethodGet, "/error", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New().EnableCache()
+			c.NewRequest(tt.method, srv.URL+tt.path).Do().Close()
+			if got := cached(t, c, tt.method, srv.URL+tt.path); got != tt.stored {
+				t.Fatalf("stored = %v, want %v", got, tt.stored)
+			}
+		})
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	a, b := New().EnableCache(), New().EnableCache()
+	a.Get(srv.URL + "/shared").Do().Close()
+	if !cached(t, a, http.MethodGet, srv.URL+"/shared") {
+		t.Fatal("response not stored")
+	}
+	if st := b.Get(srv.URL + "/shared").Do().CacheStatus(); st != CacheStatusMiss {
+		t.Fatalf("second client cache status = %q, want %q", st, CacheStatusMiss)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Fatalf("origin hits = %d, want 2", n)
+	}
+}
+
+func TestCacheKeyedByCredentials(t *testing.T) {
+	m := NewMockTransport()
+	route := m.On(http.MethodGet, "http://api.example.com/me").Respond(func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, http.StatusOK, nil, []byte(req.Header.Get("Authorization"))), nil
+	})
+	c := New().SetMockTransport(m).EnableCache()
+
+	get := func(user string) (string, string) {
+		b := c.Get("http://api.example.com/me").SetHeader("Authorization", []string{"Bearer " + user})
+		body, errs := b.String()
+		if len(errs) != 0 {
+			t.Fatal(errs)
+		}
+		return body, b.CacheStatus()
+	}
+
+	if body, st := get("alice"); body != "Bearer alice" || st != CacheStatusMiss {
+		t.Fatalf("alice: %q %s", body, st)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://api.example.com/me", nil)
+	req.Header.Set("Authorization", "Bearer alice")
+	for i := 0; i < 50; i++ {
+		if _, ok := c.cache.Get(c.cacheKey(req)); ok {
+			break
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if body, st := get("bob"); body != "Bearer bob" || st != CacheStatusMiss {
+		t.Fatalf("bob: %q %s", body, st)
+	}
+	if body, st := get("alice"); body != "Bearer alice" || st != CacheStatusHit {
+		t.Fatalf("alice again: %q %s", body, st)
+	}
+	if route.Calls() != 2 {
+		t.Fatalf("origin calls = %d, want 2", route.Calls())
+	}
+}
+
+func TestCacheKeyWithInjectedCredentials(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(r.Header.Get("Authorization") + " " + r.Header.Get("Cookie")))
+	}))
+	defer srv.Close()
+
+	c := New().EnableCache().SetCacheTTL(50*time.Millisecond).
+		SetTokenSource(func() (string, string, error) {
+			return "Bearer", "tok", nil
+		}).
+		SetHeaderFromProvider("Cookie", func(ctx context.Context) (string, error) {
+			return "session=1", nil
+		})
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Authorization", "Bearer tok")
+	req.Header.Set("Cookie", "session=1")
+	key := c.cacheKey(req)
+	stored := func() {
+		t.Helper()
+		for i := 0; i < 50; i++ {
+			if _, ok := c.cache.Get(key); ok {
+				return
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+		t.Fatal("response not stored under the key of the sent credentials")
+	}
+	get := func(want int32) {
+		t.Helper()
+		body, errs := c.Get(srv.URL).String()
+		if len(errs) != 0 || body != "Bearer tok session=1" {
+			t.Fatalf("got %q %v", body, errs)
+		}
+		if n := atomic.LoadInt32(&hits); n != want {
+			t.Fatalf("origin hits = %d, want %d", n, want)
+		}
+	}
+
+	get(1)
+	stored()
+	get(1)
+
+	time.Sleep(100 * time.Millisecond)
+	get(2)
+	stored()
+
+	c.ResetCache()
+	get(3)
+}
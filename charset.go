@@ -0,0 +1,99 @@
+package httgo
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedCharset is returned for response charsets which cannot be decoded
+var ErrUnsupportedCharset = errors.New("Unsupported Charset")
+
+// metaCharset matches <meta charset="..."> and <meta http-equiv="Content-Type" content="...; charset=...">
+var metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.\-]+)`)
+
+// windows1252 maps the 0x80-0x9f range, the rest of the charset matches ISO-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// detectCharset returns the lower-cased charset of body from its BOM, the Content-Type
+// parameter or an HTML meta tag, in that order, defaulting to utf-8
+func detectCharset(body []byte, contentType string) string {
+	switch {
+	case bytes.HasPrefix(body, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8"
+	case bytes.HasPrefix(body, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	case bytes.HasPrefix(body, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return strings.ToLower(strings.Trim(params["charset"], `"' `))
+	}
+
+	head := body
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if m := metaCharset.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+
+	return "utf-8"
+}
+
+// decodeCharset converts body from charset to UTF-8, dropping any BOM
+func decodeCharset(body []byte, charset string) ([]byte, error) {
+	switch charset {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return bytes.TrimPrefix(body, []byte{0xef, 0xbb, 0xbf}), nil
+	case "utf-16", "utf-16be", "utf-16le":
+		return decodeUTF16(body, charset == "utf-16le"), nil
+	case "iso-8859-1", "latin1", "l1", "windows-1252", "cp1252":
+		// browsers treat iso-8859-1 as windows-1252 as well
+		buf := make([]byte, 0, len(body)+len(body)/4)
+		for _, c := range body {
+			r := rune(c)
+			if c >= 0x80 && c < 0xa0 {
+				r = windows1252[c-0x80]
+			}
+			buf = utf8.AppendRune(buf, r)
+		}
+		return buf, nil
+	}
+	return nil, ErrUnsupportedCharset
+}
+
+// decodeUTF16 decodes body honoring a BOM, falling back to the given byte order
+func decodeUTF16(body []byte, littleEndian bool) []byte {
+	switch {
+	case bytes.HasPrefix(body, []byte{0xfe, 0xff}):
+		littleEndian = false
+		body = body[2:]
+	case bytes.HasPrefix(body, []byte{0xff, 0xfe}):
+		littleEndian = true
+		body = body[2:]
+	}
+
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		if littleEndian {
+			units[i] = uint16(body[2*i]) | uint16(body[2*i+1])<<8
+		} else {
+			units[i] = uint16(body[2*i])<<8 | uint16(body[2*i+1])
+		}
+	}
+
+	buf := make([]byte, 0, len(body))
+	for _, r := range utf16.Decode(units) {
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
@@ -0,0 +1,186 @@
+package httgo
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ChecksumError is returned while reading a body whose checksum does not match
+type ChecksumError struct {
+	// Source is "VerifyChecksum" or the header the expected checksum came from
+	Source   string
+	Algo     string
+	Expected string
+	Actual   string
+}
+
+// checksumBody hashes a body and checks every sum once it has been read to EOF
+type checksumBody struct {
+	io.ReadCloser
+	sums []*checksum
+	err  error
+}
+
+type checksum struct {
+	source   string
+	algo     string
+	h        hash.Hash
+	expected []byte
+}
+
+var (
+	// ErrChecksumMismatch matches *ChecksumError with errors.Is
+	ErrChecksumMismatch = errors.New("Checksum Mismatch")
+	// ErrUnsupportedChecksum is returned by VerifyChecksum for unknown algorithms
+	ErrUnsupportedChecksum = errors.New("Unsupported Checksum Algorithm")
+)
+
+func (e *ChecksumError) Error() string {
+	return ErrChecksumMismatch.Error() + ": " + e.Source + " " + e.Algo + " expected " + e.Expected + ", got " + e.Actual
+}
+
+// Unwrap returns ErrChecksumMismatch
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// VerifyChecksum checks the decoded response body against expectedHex with algo
+// ("md5", "sha1", "sha256" or "sha512"): the final read fails with *ChecksumError
+// instead of io.EOF, so the body is never handed over as complete when it is corrupt.
+// Digest, Content-Digest and Content-MD5 response headers are verified the same way
+// without calling VerifyChecksum.
+func (b *RequestBuilder) VerifyChecksum(algo, expectedHex string) *RequestBuilder {
+	expected, err := hex.DecodeString(strings.TrimSpace(expectedHex))
+	h := newChecksumHash(algo)
+	switch {
+	case h == nil:
+		b.fail(ErrPhaseBuild, ErrUnsupportedChecksum)
+	case err != nil:
+		b.fail(ErrPhaseBuild, err)
+	default:
+		b.checksums = append(b.checksums, &checksum{
+			source:   "VerifyChecksum",
+			algo:     strings.ToLower(algo),
+			h:        h,
+			expected: expected,
+		})
+	}
+	return b
+}
+
+// headerChecksums returns the checksums announced by res for its body as sent, ranges and
+// bodies decompressed by net/http are skipped since the headers describe the whole encoded body
+func headerChecksums(req *http.Request, res *http.Response) []*checksum {
+	if res.StatusCode != http.StatusOK || res.Uncompressed || !hasBody(req, res) {
+		return nil
+	}
+
+	var sums []*checksum
+	add := func(source, algo, b64 string) {
+		h := newChecksumHash(algo)
+		expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+		if h == nil || err != nil {
+			return
+		}
+		sums = append(sums, &checksum{
+			source:   source,
+			algo:     strings.ToLower(algo),
+			h:        h,
+			expected: expected,
+		})
+	}
+
+	if v := res.Header.Get("Content-MD5"); v != "" {
+		add("Content-MD5", "md5", v)
+	}
+	// RFC 3230: Digest: SHA-256=base64, MD5=base64
+	for _, d := range strings.Split(res.Header.Get("Digest"), ",") {
+		kv := strings.SplitN(strings.TrimSpace(d), "=", 2)
+		if len(kv) == 2 {
+			add("Digest", kv[0], kv[1])
+		}
+	}
+	// RFC 9530: Content-Digest: sha-256=:base64:
+	for _, d := range strings.Split(res.Header.Get("Content-Digest"), ",") {
+		kv := strings.SplitN(strings.TrimSpace(d), "=", 2)
+		if len(kv) == 2 {
+			add("Content-Digest", kv[0], strings.Trim(kv[1], ":"))
+		}
+	}
+	return sums
+}
+
+// copyChecksums returns unused copies of sums
+func copyChecksums(sums []*checksum) []*checksum {
+	var cp []*checksum
+	for _, s := range sums {
+		cp = append(cp, &checksum{
+			source:   s.source,
+			algo:     s.algo,
+			h:        newChecksumHash(s.algo),
+			expected: s.expected,
+		})
+	}
+	return cp
+}
+
+func newChecksumHash(algo string) hash.Hash {
+	switch strings.ToLower(algo) {
+	case "md5":
+		return md5.New()
+	case "sha1", "sha-1":
+		return sha1.New()
+	case "sha256", "sha-256":
+		return sha256.New()
+	case "sha512", "sha-512":
+		return sha512.New()
+	}
+	return nil
+}
+
+func verifyBody(body io.ReadCloser, sums []*checksum) io.ReadCloser {
+	if len(sums) == 0 {
+		return body
+	}
+	return &checksumBody{
+		ReadCloser: body,
+		sums:       sums,
+	}
+}
+
+func (cb *checksumBody) Read(p []byte) (int, error) {
+	if cb.err != nil {
+		return 0, cb.err
+	}
+	n, err := cb.ReadCloser.Read(p)
+	for _, s := range cb.sums {
+		s.h.Write(p[:n])
+	}
+	if err != io.EOF {
+		return n, err
+	}
+	for _, s := range cb.sums {
+		actual := s.h.Sum(nil)
+		if !bytes.Equal(actual, s.expected) {
+			cb.err = &ChecksumError{
+				Source:   s.source,
+				Algo:     s.algo,
+				Expected: hex.EncodeToString(s.expected),
+				Actual:   hex.EncodeToString(actual),
+			}
+			return n, cb.err
+		}
+	}
+	cb.err = io.EOF
+	return n, io.EOF
+}
@@ -0,0 +1,180 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerConfig configures the per-host circuit breaker
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit, defaults to 5
+	FailureThreshold int
+	// OpenDuration is how long requests fail fast before probing again, defaults to 30 seconds
+	OpenDuration time.Duration
+	// HalfOpenRequests is the number of probes which must succeed to close the circuit, defaults to 1
+	HalfOpenRequests int
+	// IsFailure classifies an attempt, transport errors and 5xx responses by default
+	IsFailure func(res *http.Response, err error) bool
+	// OnOpen is called with the host each time its circuit opens
+	OnOpen func(host string)
+}
+
+// CircuitState is the state of a host's circuit
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+type circuitBreakers struct {
+	cfg   CircuitBreakerConfig
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuit struct {
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+var (
+	// ErrCircuitOpen is returned without sending the request while the host's circuit is open
+	ErrCircuitOpen = errors.New("Circuit Breaker Open")
+)
+
+// EnableCircuitBreaker fails fast with ErrCircuitOpen for hosts which keep failing
+// and lets a few probe requests through after cfg.OpenDuration to detect recovery
+func (c *HTTPClient) EnableCircuitBreaker(cfg CircuitBreakerConfig) *HTTPClient {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenDuration <= 0 {
+		cfg.OpenDuration = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = isFailure
+	}
+	c.breakers = &circuitBreakers{
+		cfg:   cfg,
+		hosts: make(map[string]*circuit),
+	}
+	return c
+}
+
+// CircuitState returns the circuit state of host (as in URL.Host)
+func (c *HTTPClient) CircuitState(host string) CircuitState {
+	if c.breakers == nil {
+		return CircuitClosed
+	}
+	cb := c.breakers.get(host)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= c.breakers.cfg.OpenDuration {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+func (cbs *circuitBreakers) get(host string) *circuit {
+	cbs.mu.Lock()
+	defer cbs.mu.Unlock()
+	cb, ok := cbs.hosts[host]
+	if !ok {
+		cb = new(circuit)
+		cbs.hosts[host] = cb
+	}
+	return cb
+}
+
+func (cbs *circuitBreakers) roundTrip(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	cb := cbs.get(req.URL.Host)
+
+	if !cb.allow(cbs.cfg) {
+		return nil, ErrCircuitOpen
+	}
+
+	res, err := next(req)
+	if cb.done(cbs.cfg, cbs.cfg.IsFailure(res, err)) && cbs.cfg.OnOpen != nil {
+		cbs.cfg.OnOpen(req.URL.Host)
+	}
+	return res, err
+}
+
+func (cb *circuit) allow(cfg CircuitBreakerConfig) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cfg.OpenDuration {
+			return false
+		}
+		cb.state = CircuitHalfOpen
+		cb.probes = 0
+		cb.successes = 0
+		fallthrough
+	case CircuitHalfOpen:
+		if cb.probes >= cfg.HalfOpenRequests {
+			return false
+		}
+		cb.probes++
+	}
+	return true
+}
+
+// done records the outcome of an attempt and reports whether it opened the circuit
+func (cb *circuit) done(cfg CircuitBreakerConfig, failed bool) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if failed {
+			cb.state = CircuitOpen
+			cb.openedAt = time.Now()
+			return true
+		}
+		cb.successes++
+		if cb.successes >= cfg.HalfOpenRequests {
+			cb.state = CircuitClosed
+			cb.failures = 0
+		}
+	case CircuitClosed:
+		if !failed {
+			cb.failures = 0
+			return false
+		}
+		cb.failures++
+		if cb.failures >= cfg.FailureThreshold {
+			cb.state = CircuitOpen
+			cb.openedAt = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+func isFailure(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= 500
+}
@@ -0,0 +1,158 @@
+package httgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Template stamps out independent requests sharing a base URL, headers, cookies,
+// credentials, timeout and body. Unlike a RequestBuilder it is safe for concurrent use.
+type Template struct {
+	base *RequestBuilder
+	body []byte
+}
+
+// Clone returns an independent copy of the request which can be modified and sent
+// separately, e.g. from another goroutine. A request already built, see GetRequest,
+// is copied as is, its response and errors are not copied.
+func (b *RequestBuilder) Clone() *RequestBuilder {
+	nb := b.copyConfig()
+
+	if b.isRequestReady && b.req != nil {
+		body, err := requestBody(b.req)
+		if err != nil {
+			nb.fail(ErrPhaseBuild, err)
+			return nb
+		}
+		req := b.req.Clone(b.req.Context())
+		if body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			req.GetBody = b.req.GetBody
+		}
+		nb.req = req
+		nb.header = req.Header
+		nb.isRequestReady = true
+		return nb
+	}
+
+	if b.body != nil {
+		body, err := ioutil.ReadAll(b.body)
+		if err != nil {
+			nb.fail(ErrPhaseBuild, err)
+			return nb
+		}
+		b.body = bytes.NewReader(body)
+		nb.body = bytes.NewReader(body)
+	}
+	return nb
+}
+
+// Template turns the configuration of b, which must not have been built yet, into a Template.
+// b's URL becomes the base URL of the template's requests.
+func (b *RequestBuilder) Template() *Template {
+	t := &Template{
+		base: b.copyConfig(),
+	}
+	if b.body != nil {
+		body, err := ioutil.ReadAll(b.body)
+		if err != nil {
+			t.base.fail(ErrPhaseBuild, err)
+		}
+		b.body = bytes.NewReader(body)
+		t.body = body
+	}
+	return t
+}
+
+// New returns a request with method and u built from the template,
+// a relative u is appended to the template URL
+func (t *Template) New(method, u string) *RequestBuilder {
+	nb := t.base.copyConfig()
+	nb.method = method
+	nb.url = joinURL(t.base.url, u)
+	if t.body != nil {
+		nb.body = bytes.NewReader(t.body)
+	}
+	return nb
+}
+
+func (t *Template) Get(u string) *RequestBuilder {
+	return t.New(http.MethodGet, u)
+}
+
+func (t *Template) Post(u string) *RequestBuilder {
+	return t.New(http.MethodPost, u)
+}
+
+func (t *Template) Put(u string) *RequestBuilder {
+	return t.New(http.MethodPut, u)
+}
+
+func (t *Template) Patch(u string) *RequestBuilder {
+	return t.New(http.MethodPatch, u)
+}
+
+func (t *Template) Delete(u string) *RequestBuilder {
+	return t.New(http.MethodDelete, u)
+}
+
+func (t *Template) Head(u string) *RequestBuilder {
+	return t.New(http.MethodHead, u)
+}
+
+// copyConfig copies everything set on b before sending, but the body
+func (b *RequestBuilder) copyConfig() *RequestBuilder {
+	nb := &RequestBuilder{
+		client:               b.client,
+		header:               b.header.Clone(),
+		cookies:              append([]*http.Cookie(nil), b.cookies...),
+		method:               b.method,
+		url:                  b.url,
+		errResult:            b.errResult,
+		progress:             b.progress,
+		extractMaxBytes:      b.extractMaxBytes,
+		extractMaxFiles:      b.extractMaxFiles,
+		onPreconditionFailed: b.onPreconditionFailed,
+		preconditionRetries:  b.preconditionRetries,
+		timeout:              b.timeout,
+		noBody:               b.noBody,
+		deleteBody:           b.deleteBody,
+		ctx:                  b.ctx,
+		checksums:            copyChecksums(b.checksums),
+		errs:                 append([]error(nil), b.errs...),
+	}
+	if nb.header == nil {
+		nb.header = make(http.Header)
+	}
+	if b.pathParams != nil {
+		nb.pathParams = make(map[string]string, len(b.pathParams))
+		for k, v := range b.pathParams {
+			nb.pathParams[k] = v
+		}
+	}
+	if b.basic != nil {
+		basic := *b.basic
+		nb.basic = &basic
+	}
+	if b.digest != nil {
+		digest := *b.digest
+		nb.digest = &digest
+	}
+	if b.tracer != nil {
+		nb.tracer = newTracer()
+	}
+	return nb
+}
+
+// joinURL appends u to base unless u is absolute
+func joinURL(base, u string) string {
+	if base == "" || strings.Contains(u, "://") {
+		return u
+	}
+	if u == "" || strings.HasPrefix(u, "?") {
+		return base + u
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(u, "/")
+}
@@ -0,0 +1,42 @@
+package httgo
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONMarshalFunc has the signature of json.Marshal
+type JSONMarshalFunc func(v interface{}) ([]byte, error)
+
+// JSONUnmarshalFunc has the signature of json.Unmarshal
+type JSONUnmarshalFunc func(data []byte, v interface{}) error
+
+// SetJSONCodec replaces encoding/json with another implementation such as jsoniter, go-json or sonic.
+// UseJSONNumber and DisallowUnknownFields only apply to the default codec.
+func (c *HTTPClient) SetJSONCodec(marshal JSONMarshalFunc, unmarshal JSONUnmarshalFunc) *HTTPClient {
+	c.jsonMarshal = marshal
+	c.jsonUnmarshal = unmarshal
+	return c
+}
+
+func (c *HTTPClient) marshalJSON(v interface{}) ([]byte, error) {
+	if c.jsonMarshal != nil {
+		return c.jsonMarshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// SetBodyJSON encodes v as the JSON request body
+func (b *RequestBuilder) SetBodyJSON(v interface{}) *RequestBuilder {
+	data, err := b.client.marshalJSON(v)
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+	b.body = bytes.NewReader(data)
+	b.bodyStream = false
+	if b.header.Get("Content-Type") == "" {
+		b.header.Set("Content-Type", "application/json")
+	}
+	return b
+}
@@ -0,0 +1,118 @@
+package httgo
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// contentDecoder wraps a body encoded with a Content-Encoding
+type contentDecoder func(r io.Reader) (io.Reader, error)
+
+// decodedBody closes both the decoder and the underlying body
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// ErrUnsupportedContentEncoding is returned for responses with an unknown Content-Encoding
+var ErrUnsupportedContentEncoding = errors.New("Unsupported Content-Encoding")
+
+// contentDecoders holds the supported encodings, brotli and zstd are registered by
+// brotli.go and zstd.go when building with the brotli and zstd tags
+var contentDecoders = map[string]contentDecoder{
+	"gzip":    decodeGzip,
+	"x-gzip":  decodeGzip,
+	"deflate": decodeDeflate,
+}
+
+// contentEncodingPreference is the order of Accept-Encoding sent by EnableCompression
+var contentEncodingPreference = []string{"br", "zstd", "gzip", "deflate"}
+
+// EnableCompression advertises every supported Content-Encoding in Accept-Encoding,
+// preferring br and zstd when available. Responses are decoded transparently either way.
+func (c *HTTPClient) EnableCompression() *HTTPClient {
+	var encs []string
+	for _, enc := range contentEncodingPreference {
+		if _, ok := contentDecoders[enc]; ok {
+			encs = append(encs, enc)
+		}
+	}
+	c.acceptEncoding = strings.Join(encs, ", ")
+	return c
+}
+
+// decodeContent unwraps every Content-Encoding of body, applied in the listed order,
+// overrides replace the default decoders of their encodings
+func decodeContent(body io.ReadCloser, encoding string, overrides map[string]contentDecoder) (io.ReadCloser, error) {
+	encs := strings.Split(encoding, ",")
+	d := &decodedBody{
+		Reader:  body,
+		closers: []io.Closer{body},
+	}
+	for i := len(encs) - 1; i >= 0; i-- {
+		enc := strings.ToLower(strings.TrimSpace(encs[i]))
+		if enc == "" || enc == "identity" {
+			continue
+		}
+		dec, ok := overrides[enc]
+		if !ok {
+			dec, ok = contentDecoders[enc]
+		}
+		if !ok {
+			return nil, ErrUnsupportedContentEncoding
+		}
+		r, err := dec(d.Reader)
+		if err != nil {
+			return nil, err
+		}
+		d.Reader = r
+		if cl, ok := r.(io.Closer); ok {
+			d.closers = append(d.closers, cl)
+		}
+	}
+	return d, nil
+}
+
+// hasBody reports whether res may carry an encoded body
+func hasBody(req *http.Request, res *http.Response) bool {
+	switch {
+	case req.Method == http.MethodHead,
+		res.StatusCode == http.StatusNoContent,
+		res.StatusCode == http.StatusNotModified,
+		res.StatusCode == http.StatusSwitchingProtocols,
+		res.ContentLength == 0:
+		return false
+	}
+	return true
+}
+
+func (d *decodedBody) Close() error {
+	var err error
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if cerr := d.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func decodeGzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// decodeDeflate accepts both the zlib wrapped format required by RFC 9110
+// and the raw deflate streams some servers send instead
+func decodeDeflate(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	h, err := br.Peek(2)
+	if err == nil && h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,88 @@
+package httgo
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+)
+
+// UpdateFunc receives the current representation of a resource and returns the body to write back
+type UpdateFunc func(current []byte) ([]byte, error)
+
+// PutIfMatch turns the request into a PUT conditioned on etag
+func (b *RequestBuilder) PutIfMatch(etag string) *RequestBuilder {
+	b.method = http.MethodPut
+	b.header.Set("If-Match", etag)
+	return b
+}
+
+// RetryOnPreconditionFailed re-fetches the resource when the server answers 412,
+// lets fn rebuild the body from the fresh representation and retries with the new ETag,
+// up to maxRetries times.
+func (b *RequestBuilder) RetryOnPreconditionFailed(maxRetries int, fn UpdateFunc) *RequestBuilder {
+	b.preconditionRetries = maxRetries
+	b.onPreconditionFailed = fn
+	return b
+}
+
+func (b *RequestBuilder) retryPrecondition(res *http.Response) (*http.Response, error) {
+	c := b.client
+
+	for i := 0; i < b.preconditionRetries && res.StatusCode == http.StatusPreconditionFailed; i++ {
+		atomic.AddUint64(&c.stats.retries, 1)
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+
+		greq, err := http.NewRequest(http.MethodGet, b.req.URL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		greq = greq.WithContext(b.req.Context())
+		for k, v := range b.req.Header {
+			switch k {
+			case "If-Match", "Content-Type", "Content-Length":
+				continue
+			}
+			greq.Header[k] = v
+		}
+
+		gres, err := c.client.Do(greq)
+		if err != nil {
+			return nil, err
+		}
+		if gres.StatusCode/100 != 2 {
+			return gres, nil
+		}
+
+		current, err := ioutil.ReadAll(gres.Body)
+		gres.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := b.onPreconditionFailed(current)
+		if err != nil {
+			return nil, err
+		}
+
+		preq := b.req.Clone(WithAttempt(b.req.Context(), i+2, b.preconditionRetries+1))
+		preq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		preq.ContentLength = int64(len(body))
+		preq.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		if etag := gres.Header.Get("ETag"); etag != "" {
+			preq.Header.Set("If-Match", etag)
+		}
+		b.req = preq
+
+		res, err = c.client.Do(preq)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,374 @@
+package httgo
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the declarative form of the client settings, see NewFromConfig, NewFromEnv
+// and LoadConfigYAML. Zero values keep the defaults of New. Durations are nanoseconds in JSON
+// and strings such as "30s" in YAML and the environment.
+type Config struct {
+	BaseURL   string            `json:"base_url" yaml:"base_url"`
+	UserAgent string            `json:"user_agent" yaml:"user_agent"`
+	Headers   map[string]string `json:"headers" yaml:"headers"`
+
+	Timeout               time.Duration `json:"timeout" yaml:"timeout"`
+	DialTimeout           time.Duration `json:"dial_timeout" yaml:"dial_timeout"`
+	TLSHandshakeTimeout   time.Duration `json:"tls_handshake_timeout" yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `json:"response_header_timeout" yaml:"response_header_timeout"`
+	BodyReadTimeout       time.Duration `json:"body_read_timeout" yaml:"body_read_timeout"`
+
+	Proxy string `json:"proxy" yaml:"proxy"`
+
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	RootCAFile         string `json:"root_ca_file" yaml:"root_ca_file"`
+	ClientCertFile     string `json:"client_cert_file" yaml:"client_cert_file"`
+	ClientKeyFile      string `json:"client_key_file" yaml:"client_key_file"`
+
+	Retries      int           `json:"retries" yaml:"retries"`
+	RetryBackoff time.Duration `json:"retry_backoff" yaml:"retry_backoff"`
+
+	// Cache enables the in-memory cache, CacheDir a file cache instead
+	Cache    bool          `json:"cache" yaml:"cache"`
+	CacheDir string        `json:"cache_dir" yaml:"cache_dir"`
+	CacheTTL time.Duration `json:"cache_ttl" yaml:"cache_ttl"`
+
+	DisableRedirects bool `json:"disable_redirects" yaml:"disable_redirects"`
+	MaxRedirects     int  `json:"max_redirects" yaml:"max_redirects"`
+
+	MaxIdleConns        int           `json:"max_idle_conns" yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `json:"max_conns_per_host" yaml:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `json:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+}
+
+// ConfigError describes an invalid Config value, Field is the Go field name
+// or the environment variable it was read from
+type ConfigError struct {
+	Field  string
+	Reason string
+}
+
+var (
+	// ErrInvalidConfig matches *ConfigError with errors.Is
+	ErrInvalidConfig = errors.New("Invalid Config")
+)
+
+// NewFromConfig returns a client configured from cfg, or every problem of cfg joined
+func NewFromConfig(cfg Config) (*HTTPClient, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	c := New()
+	var errs []error
+	invalid := func(field string, err error) {
+		errs = append(errs, &ConfigError{
+			Field:  field,
+			Reason: err.Error(),
+		})
+	}
+
+	if cfg.BaseURL != "" {
+		c.SetBaseURL(cfg.BaseURL)
+	}
+	if cfg.UserAgent != "" {
+		c.SetUserAgent(cfg.UserAgent)
+	}
+	for k, v := range cfg.Headers {
+		c.SetDefaultHeader(k, v)
+	}
+
+	if cfg.Timeout > 0 {
+		c.SetTimeout(cfg.Timeout)
+	}
+	if cfg.DialTimeout > 0 {
+		c.SetDialTimeout(cfg.DialTimeout)
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		c.SetTLSHandshakeTimeout(cfg.TLSHandshakeTimeout)
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		c.SetResponseHeaderTimeout(cfg.ResponseHeaderTimeout)
+	}
+	if cfg.BodyReadTimeout > 0 {
+		c.SetBodyReadTimeout(cfg.BodyReadTimeout)
+	}
+
+	if cfg.Proxy != "" {
+		c.SetProxy(cfg.Proxy)
+	}
+
+	if cfg.InsecureSkipVerify {
+		c.InsecureSkipVerify()
+	}
+	if cfg.RootCAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.RootCAFile)
+		pool := x509.NewCertPool()
+		switch {
+		case err != nil:
+			invalid("RootCAFile", err)
+		case !pool.AppendCertsFromPEM(pem):
+			invalid("RootCAFile", errors.New("no PEM certificate found"))
+		default:
+			c.SetRootCAs(pool)
+		}
+	}
+	if cfg.ClientCertFile != "" {
+		n := len(c.errs)
+		c.SetClientCert(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if len(c.errs) > n {
+			invalid("ClientCertFile", c.errs[n])
+			c.errs = c.errs[:n]
+		}
+	}
+
+	if cfg.Retries > 0 {
+		c.SetRetry(cfg.Retries, cfg.RetryBackoff)
+	}
+
+	switch {
+	case cfg.CacheDir != "":
+		store, err := NewFileStore(cfg.CacheDir)
+		if err != nil {
+			invalid("CacheDir", err)
+			break
+		}
+		c.SetCacheStore(store)
+	case cfg.Cache:
+		c.EnableCache()
+	}
+	if cfg.CacheTTL > 0 {
+		c.SetCacheTTL(cfg.CacheTTL)
+	}
+
+	if cfg.DisableRedirects {
+		c.DisableRedirects()
+	} else if cfg.MaxRedirects > 0 {
+		c.SetRedirectCount(cfg.MaxRedirects)
+	}
+
+	if cfg.MaxIdleConns > 0 {
+		c.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		c.SetMaxIdleConnsPerHost(cfg.MaxIdleConnsPerHost)
+	}
+	if cfg.MaxConnsPerHost > 0 {
+		c.SetMaxConnsPerHost(cfg.MaxConnsPerHost)
+	}
+	if cfg.IdleConnTimeout > 0 {
+		c.SetIdleConnTimeout(cfg.IdleConnTimeout)
+	}
+
+	errs = append(errs, c.errs...)
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// NewFromEnv returns a client configured from the environment, see ConfigFromEnv
+func NewFromEnv(prefix string) (*HTTPClient, error) {
+	cfg, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromConfig(cfg)
+}
+
+// ConfigFromEnv reads a Config from variables named after the YAML keys in upper case,
+// e.g. MYAPP_TIMEOUT=30s and MYAPP_BASE_URL for the prefix "MYAPP". Headers are read
+// from MYAPP_HEADER_<NAME> with underscores turned into dashes, e.g. MYAPP_HEADER_X_API_KEY.
+func ConfigFromEnv(prefix string) (Config, error) {
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "_") + "_"
+	}
+
+	var cfg Config
+	var errs []error
+	lookup := func(name string, parse func(string) error) {
+		v, ok := os.LookupEnv(prefix + name)
+		if !ok || v == "" {
+			return
+		}
+		err := parse(v)
+		if err != nil {
+			errs = append(errs, &ConfigError{
+				Field:  prefix + name,
+				Reason: strconv.Quote(v) + " " + err.Error(),
+			})
+		}
+	}
+	str := func(p *string) func(string) error {
+		return func(v string) error {
+			*p = v
+			return nil
+		}
+	}
+	integer := func(p *int) func(string) error {
+		return func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New("is not an integer")
+			}
+			*p = n
+			return nil
+		}
+	}
+	boolean := func(p *bool) func(string) error {
+		return func(v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.New("is not a boolean")
+			}
+			*p = b
+			return nil
+		}
+	}
+	duration := func(p *time.Duration) func(string) error {
+		return func(v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return errors.New("is not a duration such as 30s")
+			}
+			*p = d
+			return nil
+		}
+	}
+
+	lookup("BASE_URL", str(&cfg.BaseURL))
+	lookup("USER_AGENT", str(&cfg.UserAgent))
+	lookup("TIMEOUT", duration(&cfg.Timeout))
+	lookup("DIAL_TIMEOUT", duration(&cfg.DialTimeout))
+	lookup("TLS_HANDSHAKE_TIMEOUT", duration(&cfg.TLSHandshakeTimeout))
+	lookup("RESPONSE_HEADER_TIMEOUT", duration(&cfg.ResponseHeaderTimeout))
+	lookup("BODY_READ_TIMEOUT", duration(&cfg.BodyReadTimeout))
+	lookup("PROXY", str(&cfg.Proxy))
+	lookup("INSECURE_SKIP_VERIFY", boolean(&cfg.InsecureSkipVerify))
+	lookup("ROOT_CA_FILE", str(&cfg.RootCAFile))
+	lookup("CLIENT_CERT_FILE", str(&cfg.ClientCertFile))
+	lookup("CLIENT_KEY_FILE", str(&cfg.ClientKeyFile))
+	lookup("RETRIES", integer(&cfg.Retries))
+	lookup("RETRY_BACKOFF", duration(&cfg.RetryBackoff))
+	lookup("CACHE", boolean(&cfg.Cache))
+	lookup("CACHE_DIR", str(&cfg.CacheDir))
+	lookup("CACHE_TTL", duration(&cfg.CacheTTL))
+	lookup("DISABLE_REDIRECTS", boolean(&cfg.DisableRedirects))
+	lookup("MAX_REDIRECTS", integer(&cfg.MaxRedirects))
+	lookup("MAX_IDLE_CONNS", integer(&cfg.MaxIdleConns))
+	lookup("MAX_IDLE_CONNS_PER_HOST", integer(&cfg.MaxIdleConnsPerHost))
+	lookup("MAX_CONNS_PER_HOST", integer(&cfg.MaxConnsPerHost))
+	lookup("IDLE_CONN_TIMEOUT", duration(&cfg.IdleConnTimeout))
+
+	for _, kv := range os.Environ() {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(kv[:i], prefix+"HEADER_") {
+			continue
+		}
+		name := strings.Replace(strings.TrimPrefix(kv[:i], prefix+"HEADER_"), "_", "-", -1)
+		if cfg.Headers == nil {
+			cfg.Headers = make(map[string]string)
+		}
+		cfg.Headers[textproto.CanonicalMIMEHeaderKey(name)] = kv[i+1:]
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// Validate reports every invalid or conflicting value of cfg joined, files are checked by NewFromConfig
+func (cfg Config) Validate() error {
+	var errs []error
+	invalid := func(field, reason string) {
+		errs = append(errs, &ConfigError{
+			Field:  field,
+			Reason: reason,
+		})
+	}
+
+	if cfg.BaseURL != "" {
+		if _, err := checkURL(cfg.BaseURL); err != nil {
+			invalid("BaseURL", strconv.Quote(cfg.BaseURL)+" is not an absolute URL")
+		}
+	}
+	if cfg.Proxy != "" {
+		if _, err := checkURL(cfg.Proxy); err != nil {
+			invalid("Proxy", strconv.Quote(cfg.Proxy)+" is not an absolute URL")
+		}
+	}
+	for k, v := range cfg.Headers {
+		if !validFieldName(k) {
+			invalid("Headers", strconv.Quote(k)+" is not a valid header name")
+		}
+		if strings.ContainsAny(v, "\r\n\x00") {
+			invalid("Headers", strconv.Quote(k)+" has a control character in its value")
+		}
+	}
+
+	for _, d := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"Timeout", cfg.Timeout},
+		{"DialTimeout", cfg.DialTimeout},
+		{"TLSHandshakeTimeout", cfg.TLSHandshakeTimeout},
+		{"ResponseHeaderTimeout", cfg.ResponseHeaderTimeout},
+		{"BodyReadTimeout", cfg.BodyReadTimeout},
+		{"RetryBackoff", cfg.RetryBackoff},
+		{"CacheTTL", cfg.CacheTTL},
+		{"IdleConnTimeout", cfg.IdleConnTimeout},
+	} {
+		if d.value < 0 {
+			invalid(d.field, "must not be negative, got "+d.value.String())
+		}
+	}
+	for _, n := range []struct {
+		field string
+		value int
+	}{
+		{"Retries", cfg.Retries},
+		{"MaxRedirects", cfg.MaxRedirects},
+		{"MaxIdleConns", cfg.MaxIdleConns},
+		{"MaxIdleConnsPerHost", cfg.MaxIdleConnsPerHost},
+		{"MaxConnsPerHost", cfg.MaxConnsPerHost},
+	} {
+		if n.value < 0 {
+			invalid(n.field, "must not be negative, got "+strconv.Itoa(n.value))
+		}
+	}
+
+	if (cfg.ClientCertFile == "") != (cfg.ClientKeyFile == "") {
+		invalid("ClientCertFile", "ClientCertFile and ClientKeyFile must be set together")
+	}
+	if cfg.RetryBackoff > 0 && cfg.Retries == 0 {
+		invalid("RetryBackoff", "has no effect without Retries")
+	}
+	if cfg.CacheTTL > 0 && !cfg.Cache && cfg.CacheDir == "" {
+		invalid("CacheTTL", "has no effect without Cache or CacheDir")
+	}
+	if cfg.DisableRedirects && cfg.MaxRedirects > 0 {
+		invalid("MaxRedirects", "conflicts with DisableRedirects")
+	}
+	if cfg.MaxConnsPerHost > 0 && cfg.MaxIdleConnsPerHost > cfg.MaxConnsPerHost {
+		invalid("MaxIdleConnsPerHost", "exceeds MaxConnsPerHost")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *ConfigError) Error() string {
+	return ErrInvalidConfig.Error() + " " + e.Field + ": " + e.Reason
+}
+
+// Unwrap returns ErrInvalidConfig
+func (e *ConfigError) Unwrap() error {
+	return ErrInvalidConfig
+}
@@ -0,0 +1,53 @@
+package httgo
+
+import (
+	"context"
+)
+
+// WithContext sends the request, its redirect hops, retries, resumed downloads and
+// event stream reconnections with ctx
+func (b *RequestBuilder) WithContext(ctx context.Context) *RequestBuilder {
+	b.ctx = ctx
+	return b
+}
+
+// Cancel aborts the in-flight request chain, including pending redirects, retries and
+// reconnections, and makes a request not sent yet fail with context.Canceled.
+// Unlike the other methods it may be called from any goroutine.
+func (b *RequestBuilder) Cancel() {
+	b.cmu.Lock()
+	b.canceled = true
+	abort := b.abort
+	b.cmu.Unlock()
+	if abort != nil {
+		abort()
+	}
+}
+
+// chainContext derives the cancellable context of the request chain from the
+// WithContext context, or the context of the request when none was given
+func (b *RequestBuilder) chainContext() (context.Context, context.CancelFunc) {
+	parent := b.ctx
+	if parent == nil {
+		parent = b.req.Context()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	b.cmu.Lock()
+	b.abort = cancel
+	canceled := b.canceled
+	b.cmu.Unlock()
+
+	if canceled {
+		cancel()
+	}
+	return ctx, cancel
+}
+
+// releaseChain releases the request chain once the body is closed,
+// unless a download holds it to resume the transfer
+func (b *RequestBuilder) releaseChain() {
+	if !b.held {
+		b.cancel()
+	}
+}
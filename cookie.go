@@ -0,0 +1,202 @@
+package httgo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// persistentJar is a cookie jar saving every cookie to a JSON file,
+// cookiejar.Jar does the matching while cookies mirrors its content with all attributes
+type persistentJar struct {
+	*cookiejar.Jar
+	path    string
+	client  *HTTPClient
+	mu      sync.Mutex
+	cookies map[string]*storedCookie
+}
+
+// storedCookie is a cookie as persisted to disk
+type storedCookie struct {
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Domain   string        `json:"domain"`
+	HostOnly bool          `json:"host_only,omitempty"`
+	Path     string        `json:"path"`
+	Expires  time.Time     `json:"expires,omitempty"`
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"http_only,omitempty"`
+	SameSite http.SameSite `json:"same_site,omitempty"`
+}
+
+// SetPersistentCookieJar replaces the cookie jar with one loaded from and saved to path,
+// so sessions survive process restarts. Expired cookies are dropped, session cookies
+// (without expiry) are kept, and the Secure and HttpOnly flags are preserved.
+func (c *HTTPClient) SetPersistentCookieJar(path string) *HTTPClient {
+	jar, err := cookiejar.New(&cookiejar.Options{})
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+		return c
+	}
+	pj := &persistentJar{
+		Jar:     jar,
+		path:    path,
+		client:  c,
+		cookies: make(map[string]*storedCookie),
+	}
+	err = pj.load()
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+		return c
+	}
+	c.cjar = jar
+	c.client.Jar = pj
+	return c
+}
+
+// GetCookies returns the cookies the jar would send to u, only their names and values are set
+func (c *HTTPClient) GetCookies(u string) []*http.Cookie {
+	if c.client.Jar == nil {
+		return nil
+	}
+	parsedURL, err := checkURL(u)
+	if err != nil {
+		return nil
+	}
+	return c.client.Jar.Cookies(parsedURL)
+}
+
+func (pj *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	pj.Jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	pj.mu.Lock()
+	defer pj.mu.Unlock()
+	for _, ck := range cookies {
+		sc := newStoredCookie(u, ck, now)
+		key := sc.Domain + ";" + sc.Path + ";" + sc.Name
+		if !sc.Expires.IsZero() && !sc.Expires.After(now) {
+			delete(pj.cookies, key)
+			continue
+		}
+		pj.cookies[key] = sc
+	}
+	err := pj.save(now)
+	if err != nil && pj.client.logger != nil {
+		pj.client.logger.Printf("cookie jar: %v", err)
+	}
+}
+
+func newStoredCookie(u *url.URL, ck *http.Cookie, now time.Time) *storedCookie {
+	sc := &storedCookie{
+		Name:     ck.Name,
+		Value:    ck.Value,
+		Domain:   strings.ToLower(strings.TrimPrefix(ck.Domain, ".")),
+		Path:     ck.Path,
+		Secure:   ck.Secure,
+		HttpOnly: ck.HttpOnly,
+		SameSite: ck.SameSite,
+	}
+	if sc.Domain == "" {
+		sc.Domain = strings.ToLower(u.Hostname())
+		sc.HostOnly = true
+	}
+	if sc.Path == "" || sc.Path[0] != '/' {
+		// the default path is the directory of the request path (RFC 6265 section 5.1.4)
+		sc.Path = "/"
+		if i := strings.LastIndex(u.Path, "/"); i > 0 {
+			sc.Path = u.Path[:i]
+		}
+	}
+	switch {
+	case ck.MaxAge < 0:
+		sc.Expires = now
+	case ck.MaxAge > 0:
+		sc.Expires = now.Add(time.Duration(ck.MaxAge) * time.Second)
+	case !ck.Expires.IsZero():
+		sc.Expires = ck.Expires
+	}
+	return sc
+}
+
+func (pj *persistentJar) load() error {
+	data, err := ioutil.ReadFile(pj.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var stored []*storedCookie
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, sc := range stored {
+		if !sc.Expires.IsZero() && !sc.Expires.After(now) {
+			continue
+		}
+		pj.cookies[sc.Domain+";"+sc.Path+";"+sc.Name] = sc
+
+		scheme := "http"
+		if sc.Secure {
+			scheme = "https"
+		}
+		ck := &http.Cookie{
+			Name:     sc.Name,
+			Value:    sc.Value,
+			Path:     sc.Path,
+			Expires:  sc.Expires,
+			Secure:   sc.Secure,
+			HttpOnly: sc.HttpOnly,
+			SameSite: sc.SameSite,
+		}
+		if !sc.HostOnly {
+			ck.Domain = sc.Domain
+		}
+		pj.Jar.SetCookies(&url.URL{
+			Scheme: scheme,
+			Host:   sc.Domain,
+			Path:   sc.Path,
+		}, []*http.Cookie{ck})
+	}
+	return nil
+}
+
+// save writes the unexpired cookies atomically, pj.mu must be held
+func (pj *persistentJar) save(now time.Time) error {
+	stored := make([]*storedCookie, 0, len(pj.cookies))
+	for key, sc := range pj.cookies {
+		if !sc.Expires.IsZero() && !sc.Expires.After(now) {
+			delete(pj.cookies, key)
+			continue
+		}
+		stored = append(stored, sc)
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(pj.path), ".cookies-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), pj.path)
+}
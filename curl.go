@@ -0,0 +1,86 @@
+package httgo
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// AsCurlCommand returns a curl command line reproducing the prepared request,
+// including headers, cookies, credentials and body, e.g. to share with API vendors
+func (b *RequestBuilder) AsCurlCommand() (string, error) {
+	b.newRequest()
+	if !b.isRequestReady {
+		return "", b.Err()
+	}
+	req := b.req
+
+	body, err := requestBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := []string{"curl"}
+	if req.Method != http.MethodGet || len(body) != 0 {
+		cmd = append(cmd, "-X", shellQuote(req.Method))
+	}
+	cmd = append(cmd, shellQuote(req.URL.String()))
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range req.Header[k] {
+			cmd = append(cmd, "-H", shellQuote(k+": "+v))
+		}
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		cmd = append(cmd, "-H", shellQuote("Host: "+req.Host))
+	}
+
+	if len(body) != 0 {
+		cmd = append(cmd, "--data-binary", shellQuote(string(body)))
+	}
+
+	return strings.Join(cmd, " "), nil
+}
+
+// requestBody returns the body of req without consuming it,
+// buffering bodies which cannot be replayed
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,145 @@
+package httgo
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// Logger receives debug dumps, *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type debugBody struct {
+	io.ReadCloser
+	client *HTTPClient
+	logger Logger
+	req    *http.Request
+	start  time.Time
+	buf    bytes.Buffer
+	limit  int
+	n      int64
+	logged bool
+}
+
+const defaultDebugBodyLimit = 4096
+
+// EnableDebug logs every request and response to stderr
+func (c *HTTPClient) EnableDebug() *HTTPClient {
+	if c.logger == nil {
+		c.logger = log.New(os.Stderr, "[httgo] ", log.LstdFlags)
+	}
+	return c
+}
+
+// SetLogger logs every request and response to l, nil disables debugging
+func (c *HTTPClient) SetLogger(l Logger) *HTTPClient {
+	c.logger = l
+	return c
+}
+
+// SetDebugBodyLimit sets how many body bytes are included in debug dumps
+func (c *HTTPClient) SetDebugBodyLimit(n int) *HTTPClient {
+	c.debugBodyLimit = n
+	return c
+}
+
+func (c *HTTPClient) debugRoundTrip(req *http.Request) (*http.Response, error) {
+	limit := c.debugBodyLimit
+	if limit == 0 {
+		limit = defaultDebugBodyLimit
+	}
+
+	var body []byte
+	if req.GetBody != nil && limit > 0 {
+		rc, err := req.GetBody()
+		if err == nil {
+			body, _ = ioutil.ReadAll(io.LimitReader(rc, int64(limit)+1))
+			rc.Close()
+		}
+	}
+	c.logger.Printf("--> %s %s %s\n%s\n%s", req.Method, req.URL, req.Proto, dumpHeader(req.Header), truncate(c.redactBody(body), limit))
+
+	start := time.Now()
+	res, err := c.baseRoundTrip(req)
+	if err != nil {
+		c.logger.Printf("<-- %s %s error: %v (%s)", req.Method, req.URL, err, time.Since(start))
+		return res, err
+	}
+
+	c.logger.Printf("<-- %s %s %s %s (%s)\n%s", req.Method, req.URL, res.Proto, res.Status, time.Since(start), dumpHeader(res.Header))
+
+	if res.StatusCode != http.StatusSwitchingProtocols && res.Body != nil && limit > 0 {
+		res.Body = &debugBody{
+			ReadCloser: res.Body,
+			client:     c,
+			logger:     c.logger,
+			req:        req,
+			start:      start,
+			limit:      limit,
+		}
+	}
+
+	return res, nil
+}
+
+func (d *debugBody) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	d.n += int64(n)
+	if rest := d.limit + 1 - d.buf.Len(); rest > 0 {
+		if rest > n {
+			rest = n
+		}
+		d.buf.Write(p[:rest])
+	}
+	if err == io.EOF {
+		d.log()
+	}
+	return n, err
+}
+
+func (d *debugBody) Close() error {
+	d.log()
+	return d.ReadCloser.Close()
+}
+
+func (d *debugBody) log() {
+	if d.logged {
+		return
+	}
+	d.logged = true
+	d.logger.Printf("<-- %s %s body %d bytes (%s)\n%s", d.req.Method, d.req.URL, d.n, time.Since(d.start), truncate(d.client.redactBody(d.buf.Bytes()), d.limit))
+}
+
+func dumpHeader(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		for _, v := range h[k] {
+			switch k {
+			case "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie":
+				v = "***"
+			}
+			buf.WriteString(k + ": " + v + "\n")
+		}
+	}
+	return buf.String()
+}
+
+func truncate(b []byte, limit int) string {
+	if len(b) > limit {
+		return string(b[:limit]) + "...(truncated)"
+	}
+	return string(b)
+}
@@ -0,0 +1,36 @@
+package httgo
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugMasksCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	errs := New().SetLogger(log.New(&buf, "", 0)).Get(srv.URL).
+		SetHeader("Authorization", []string{"Bearer token-secret"}).
+		SetHeader("Cookie", []string{"session=client-secret"}).
+		Do().Close()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	out := buf.String()
+	for _, secret := range []string{"token-secret", "client-secret", "server-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("debug output leaks %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "Set-Cookie: ***") {
+		t.Errorf("debug output misses the masked Set-Cookie header:\n%s", out)
+	}
+}
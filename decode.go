@@ -0,0 +1,158 @@
+package httgo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+)
+
+var (
+	utf8BOM   = []byte{0xEF, 0xBB, 0xBF}
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// LenientJSON makes JSON decoding tolerate trailing commas and NaN/Infinity literals
+func (c *HTTPClient) LenientJSON() *HTTPClient {
+	c.lenientJSON = true
+	return c
+}
+
+// UseJSONNumber decodes JSON numbers into json.Number instead of float64
+func (c *HTTPClient) UseJSONNumber() *HTTPClient {
+	c.jsonUseNumber = true
+	return c
+}
+
+// DisallowUnknownFields makes JSON decoding fail on fields missing from the destination struct
+func (c *HTTPClient) DisallowUnknownFields() *HTTPClient {
+	c.jsonDisallowUnknown = true
+	return c
+}
+
+func (c *HTTPClient) decodeJSON(r io.Reader, d interface{}) error {
+	r, err := jsonReader(r)
+	if err != nil {
+		return err
+	}
+	if c.lenientJSON || c.jsonUnmarshal != nil {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if c.lenientJSON {
+			b = sanitizeJSON(b)
+		}
+		if c.jsonUnmarshal != nil {
+			return c.jsonUnmarshal(b, d)
+		}
+		r = bytes.NewReader(b)
+	}
+	dec := json.NewDecoder(r)
+	if c.jsonUseNumber {
+		dec.UseNumber()
+	}
+	if c.jsonDisallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(d)
+}
+
+func (c *HTTPClient) decodeXML(r io.Reader, d interface{}) error {
+	return xml.NewDecoder(skipBOM(r)).Decode(d)
+}
+
+// jsonReader undoes what some servers, several Microsoft APIs among them, apply to JSON
+// without declaring it: gzip without Content-Encoding and UTF-16 with or without BOM.
+// A UTF-8 BOM is dropped. JSON text starts with ASCII, so the first bytes tell them apart.
+func jsonReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(2)
+	if len(head) < 2 {
+		return br, nil
+	}
+
+	var littleEndian bool
+	switch {
+	case bytes.Equal(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return jsonReader(zr)
+	case head[0] == 0xfe && head[1] == 0xff, head[0] == 0 && head[1] != 0:
+	case head[0] == 0xff && head[1] == 0xfe, head[0] != 0 && head[1] == 0:
+		littleEndian = true
+	default:
+		return skipBOM(br), nil
+	}
+
+	body, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(decodeUTF16(body, littleEndian)), nil
+}
+
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	b, err := br.Peek(len(utf8BOM))
+	if err == nil && bytes.Equal(b, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
+// sanitizeJSON removes trailing commas and replaces NaN/Infinity literals with null
+func sanitizeJSON(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		ch := b[i]
+		switch {
+		case ch == '"':
+			j := i + 1
+			for ; j < len(b); j++ {
+				if b[j] == '\\' {
+					j++
+					continue
+				}
+				if b[j] == '"' {
+					break
+				}
+			}
+			if j >= len(b) {
+				j = len(b) - 1
+			}
+			out = append(out, b[i:j+1]...)
+			i = j
+		case ch == ',':
+			j := i + 1
+			for j < len(b) && isJSONSpace(b[j]) {
+				j++
+			}
+			if j < len(b) && (b[j] == '}' || b[j] == ']') {
+				continue
+			}
+			out = append(out, ch)
+		case bytes.HasPrefix(b[i:], []byte("NaN")):
+			out = append(out, "null"...)
+			i += len("NaN") - 1
+		case bytes.HasPrefix(b[i:], []byte("-Infinity")):
+			out = append(out, "null"...)
+			i += len("-Infinity") - 1
+		case bytes.HasPrefix(b[i:], []byte("Infinity")):
+			out = append(out, "null"...)
+			i += len("Infinity") - 1
+		default:
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+func isJSONSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
@@ -0,0 +1,32 @@
+package httgo
+
+import (
+	"context"
+	"net"
+)
+
+// DialContextFunc dials the connection for addr, as http.Transport.DialContext
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// SetDialer replaces the dialer used for new connections
+func (c *HTTPClient) SetDialer(d *net.Dialer) *HTTPClient {
+	if d != nil {
+		c.dialer = d
+	}
+	return c
+}
+
+// SetDialContext dials every new connection with fn instead of the dialer.
+// DNS fallback and the dial-time SSRF check are skipped, since fn decides where to connect.
+func (c *HTTPClient) SetDialContext(fn DialContextFunc) *HTTPClient {
+	c.dialContextFunc = fn
+	return c
+}
+
+// SetUnixSocket sends every request over the unix domain socket at path, e.g. "/var/run/docker.sock",
+// while URLs such as "http://docker/v1.41/containers/json" keep selecting the path and Host header
+func (c *HTTPClient) SetUnixSocket(path string) *HTTPClient {
+	return c.SetDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return c.dialer.DialContext(ctx, "unix", path)
+	})
+}
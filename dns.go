@@ -0,0 +1,186 @@
+package httgo
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dnsCache remembers the last successful lookup of every host
+type dnsCache struct {
+	mu            sync.RWMutex
+	entries       map[string]*dnsEntry
+	resolver      *net.Resolver
+	lookupTimeout time.Duration
+	fallback      bool
+	maxStale      time.Duration
+	ttl           time.Duration
+	roundRobin    bool
+}
+
+type dnsEntry struct {
+	addrs      []string
+	resolvedAt time.Time
+	next       uint32
+}
+
+// SetResolver resolves host names with r instead of the system resolver
+func (c *HTTPClient) SetResolver(r *net.Resolver) *HTTPClient {
+	c.resolver = r
+	c.dialer.Resolver = r
+	if c.dns != nil {
+		c.dns.resolver = r
+	}
+	return c
+}
+
+// EnableDNSCache reuses the addresses of a host for ttl after it was resolved
+// instead of asking the resolver on every new connection
+func (c *HTTPClient) EnableDNSCache(ttl time.Duration) *HTTPClient {
+	c.dnsCache().ttl = ttl
+	return c
+}
+
+// EnableDNSRoundRobin rotates the first address dialed over the A/AAAA records of a host,
+// spreading new connections over every record instead of always preferring the first one
+func (c *HTTPClient) EnableDNSRoundRobin() *HTTPClient {
+	c.dnsCache().roundRobin = true
+	return c
+}
+
+// EnableDNSFallback bounds DNS lookups by lookupTimeout and, when a lookup fails,
+// dials the last known good addresses of the host if they are younger than maxStale.
+// A zero maxStale accepts addresses of any age.
+func (c *HTTPClient) EnableDNSFallback(lookupTimeout, maxStale time.Duration) *HTTPClient {
+	d := c.dnsCache()
+	d.fallback = true
+	d.lookupTimeout = lookupTimeout
+	d.maxStale = maxStale
+	return c
+}
+
+func (c *HTTPClient) dnsCache() *dnsCache {
+	if c.dns == nil {
+		c.dns = &dnsCache{
+			entries:  make(map[string]*dnsEntry),
+			resolver: c.resolver,
+		}
+	}
+	return c.dns
+}
+
+func (c *HTTPClient) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := c.dialResolved(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	conn = c.stats.conn(addr, conn)
+	if c.rawHeaders {
+		conn = &rawConn{
+			Conn: conn,
+		}
+	}
+	return conn, nil
+}
+
+func (c *HTTPClient) dialResolved(ctx context.Context, network, addr string) (net.Conn, error) {
+	if c.dialContextFunc != nil {
+		return c.dialContextFunc(ctx, network, addr)
+	}
+	if c.dns == nil {
+		return c.dial(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dial(ctx, network, addr)
+	}
+
+	addrs, err := c.dns.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range addrs {
+		var conn net.Conn
+		conn, err = c.dial(ctx, network, net.JoinHostPort(a, port))
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (d *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	if d.ttl > 0 {
+		d.mu.RLock()
+		e, ok := d.entries[host]
+		d.mu.RUnlock()
+		if ok && time.Since(e.resolvedAt) < d.ttl {
+			return d.order(e), nil
+		}
+	}
+
+	r := d.resolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	lctx := ctx
+	if d.lookupTimeout > 0 {
+		var cancel context.CancelFunc
+		lctx, cancel = context.WithTimeout(ctx, d.lookupTimeout)
+		defer cancel()
+	}
+
+	ips, err := r.LookupIPAddr(lctx, host)
+	if err == nil && len(ips) != 0 {
+		addrs := make([]string, len(ips))
+		for i, ip := range ips {
+			addrs[i] = ip.String()
+		}
+		e := &dnsEntry{
+			addrs:      addrs,
+			resolvedAt: time.Now(),
+		}
+		d.mu.Lock()
+		if prev, ok := d.entries[host]; ok {
+			e.next = atomic.LoadUint32(&prev.next)
+		}
+		d.entries[host] = e
+		d.mu.Unlock()
+		return d.order(e), nil
+	}
+
+	d.mu.RLock()
+	e, ok := d.entries[host]
+	d.mu.RUnlock()
+	if d.fallback && ok && ctx.Err() == nil && (d.maxStale <= 0 || time.Since(e.resolvedAt) <= d.maxStale) {
+		return d.order(e), nil
+	}
+
+	if err == nil {
+		err = &net.DNSError{
+			Err:        "no such host",
+			Name:       host,
+			IsNotFound: true,
+		}
+	}
+	return nil, err
+}
+
+// order returns the addresses of e, rotated by one on every call when round-robin is enabled
+func (d *dnsCache) order(e *dnsEntry) []string {
+	if !d.roundRobin || len(e.addrs) < 2 {
+		return e.addrs
+	}
+	n := int(atomic.AddUint32(&e.next, 1)-1) % len(e.addrs)
+	addrs := make([]string, 0, len(e.addrs))
+	addrs = append(addrs, e.addrs[n:]...)
+	return append(addrs, e.addrs[:n]...)
+}
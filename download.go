@@ -0,0 +1,308 @@
+package httgo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressFunc receives the number of bytes written so far and the expected total (-1 when unknown)
+type ProgressFunc func(written, total int64)
+
+type progressWriter struct {
+	w       io.Writer
+	written int64
+	total   int64
+	fn      ProgressFunc
+}
+
+const maxResumeAttempts = 3
+
+var (
+	// ErrInvalidContentRange is returned when a 206 response does not match the requested range
+	ErrInvalidContentRange = errors.New("Invalid Content-Range")
+)
+
+// OnProgress registers a callback invoked while Download or DownloadFile writes the body
+func (b *RequestBuilder) OnProgress(fn ProgressFunc) *RequestBuilder {
+	b.progress = fn
+	return b
+}
+
+// Download streams the response body into w without buffering it in memory.
+// Interrupted transfers are resumed with Range requests when the server advertises Accept-Ranges,
+// up to the SetRetry count (3 by default) and only while the ETag or Last-Modified is unchanged.
+func (b *RequestBuilder) Download(w io.Writer) (int64, []error) {
+	res := b.response()
+	if res == nil {
+		return 0, b.errs
+	}
+	if !b.downloadable(res) {
+		return 0, b.errs
+	}
+	n, err := b.download(w, res, 0)
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return n, b.errs
+}
+
+// DownloadFile streams the response body into the file at path.
+// When the file already holds a partial download, only the remaining bytes are requested.
+func (b *RequestBuilder) DownloadFile(path string) (int64, []error) {
+	var offset int64
+	if !b.isRequested {
+		fi, err := os.Stat(path)
+		if err == nil && fi.Mode().IsRegular() && fi.Size() > 0 {
+			offset = fi.Size()
+			b.header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		}
+	}
+
+	res := b.response()
+	if res == nil {
+		return 0, b.errs
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		switch res.StatusCode {
+		case http.StatusPartialContent:
+			_, _, err := checkPartialContent(res, offset)
+			if err != nil {
+				res.Body.Close()
+				b.fail(ErrPhaseResponse, ErrInvalidContentRange)
+				return 0, b.errs
+			}
+			flag = os.O_WRONLY | os.O_APPEND
+		case http.StatusRequestedRangeNotSatisfiable:
+			// the file is already complete
+			res.Body.Close()
+			return 0, b.errs
+		default:
+			offset = 0
+		}
+	}
+
+	if !b.downloadable(res) {
+		return 0, b.errs
+	}
+
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		res.Body.Close()
+		b.fail(ErrPhaseBody, err)
+		return 0, b.errs
+	}
+
+	n, err := b.download(f, res, offset)
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	err = f.Close()
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return n, b.errs
+}
+
+func (b *RequestBuilder) downloadable(res *http.Response) bool {
+	if res.StatusCode < 400 {
+		return true
+	}
+	res.Body.Close()
+	if !b.client.failOnHTTPError && b.errResult == nil {
+		b.fail(ErrPhaseResponse, &HTTPError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Header:     res.Header,
+		})
+	}
+	return false
+}
+
+func (b *RequestBuilder) download(w io.Writer, res *http.Response, offset int64) (int64, error) {
+	pw := &progressWriter{
+		w:       w,
+		written: offset,
+		total:   responseTotal(res, offset),
+		fn:      b.progress,
+	}
+
+	// closing the interrupted body must not cancel the resumed requests
+	b.held = true
+	defer func() {
+		b.held = false
+		if b.cancel != nil {
+			b.cancel()
+		}
+	}()
+
+	c := b.client
+	attempts, backoff := maxResumeAttempts, time.Duration(0)
+	if c.retries > 0 {
+		attempts, backoff = c.retries, c.retryBackoff
+	}
+
+	validator := rangeValidator(res)
+	for attempt := 0; ; attempt++ {
+		_, err := io.Copy(pw, res.Body)
+		res.Body.Close()
+		if err == nil {
+			return pw.written - offset, nil
+		}
+
+		if attempt >= attempts || errors.Is(err, ErrChecksumMismatch) || errors.Is(err, ErrResponseTooLarge) ||
+			!b.resumable(res) || b.req.Context().Err() != nil {
+			return pw.written - offset, err
+		}
+
+		if backoff > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-b.req.Context().Done():
+				t.Stop()
+				return pw.written - offset, err
+			case <-t.C:
+			}
+			backoff *= 2
+		}
+
+		atomic.AddUint64(&c.stats.retries, 1)
+		rres, rerr := b.resume(pw.written, validator, attempt+2, attempts+1)
+		if rerr != nil {
+			return pw.written - offset, err
+		}
+		rerr = b.guardResumed(rres, pw.written-offset)
+		if rerr != nil {
+			return pw.written - offset, rerr
+		}
+		res = rres
+	}
+}
+
+// guardResumed wraps the body of the resumed response like do() wraps the first one:
+// the announced and VerifyChecksum sums carry on over the resumed bytes, reads are bound by
+// SetBodyReadTimeout and SetMaxResponseBodySize keeps counting from the read bytes
+func (b *RequestBuilder) guardResumed(res *http.Response, read int64) error {
+	c := b.client
+	res.Body = verifyBody(res.Body, b.headerSums)
+	if c.bodyReadTimeout > 0 {
+		res.Body = newIdleTimeoutBody(res.Body, c.bodyReadTimeout)
+	}
+	if c.maxResponseBodySize > 0 {
+		remaining := c.maxResponseBodySize - read
+		if res.ContentLength > remaining {
+			res.Body.Close()
+			return ErrResponseTooLarge
+		}
+		res.Body = &limitBody{
+			ReadCloser: res.Body,
+			remaining:  remaining,
+		}
+	}
+	res.Body = verifyBody(res.Body, b.checksums)
+	return nil
+}
+
+// rangeValidator returns the If-Range validator of res, a strong ETag or else Last-Modified
+func rangeValidator(res *http.Response) string {
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return res.Header.Get("Last-Modified")
+}
+
+// resumable reports whether the rest of res can be requested by offset,
+// which is not the case once its Content-Encoding has been decoded
+func (b *RequestBuilder) resumable(res *http.Response) bool {
+	if b.req == nil || (b.req.Method != http.MethodGet && b.req.Method != http.MethodHead) || res.Uncompressed {
+		return false
+	}
+	return strings.Contains(res.Header.Get("Accept-Ranges"), "bytes")
+}
+
+// resume requests the bytes from offset on, the If-Range validator makes the server
+// answer with the whole, changed representation instead, which fails the download
+func (b *RequestBuilder) resume(offset int64, validator string, attempt, max int) (*http.Response, error) {
+	req := b.req.Clone(WithAttempt(b.req.Context(), attempt, max))
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	req.Header.Set("Accept-Encoding", "identity")
+	if validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+
+	res, err := b.client.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = checkPartialContent(res, offset)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.fn != nil {
+		p.fn(p.written, p.total)
+	}
+	return n, err
+}
+
+func responseTotal(res *http.Response, offset int64) int64 {
+	if res.StatusCode == http.StatusPartialContent {
+		_, _, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err == nil {
+			return total
+		}
+		return -1
+	}
+	if res.ContentLength < 0 {
+		return -1
+	}
+	return offset + res.ContentLength
+}
+
+// parseContentRange parses "bytes start-end/total", total is -1 when it is "*"
+func parseContentRange(cr string) (start, end, total int64, err error) {
+	if !strings.HasPrefix(cr, "bytes ") {
+		return 0, 0, 0, ErrInvalidContentRange
+	}
+	cr = strings.TrimPrefix(cr, "bytes ")
+
+	slash := strings.IndexByte(cr, '/')
+	dash := strings.IndexByte(cr, '-')
+	if slash < 0 || dash < 0 || dash > slash {
+		return 0, 0, 0, ErrInvalidContentRange
+	}
+
+	start, err = strconv.ParseInt(cr[:dash], 10, 64)
+	if err != nil {
+		return 0, 0, 0, ErrInvalidContentRange
+	}
+	end, err = strconv.ParseInt(cr[dash+1:slash], 10, 64)
+	if err != nil || end < start {
+		return 0, 0, 0, ErrInvalidContentRange
+	}
+
+	total = -1
+	if cr[slash+1:] != "*" {
+		total, err = strconv.ParseInt(cr[slash+1:], 10, 64)
+		if err != nil || total <= end {
+			return 0, 0, 0, ErrInvalidContentRange
+		}
+	}
+
+	return start, end, total, nil
+}
@@ -0,0 +1,67 @@
+package httgo
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadResumeLimits(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("ETag", `"v1"`)
+		if rng := r.Header.Get("Range"); rng != "" {
+			from, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Range", "bytes "+strconv.Itoa(from)+"-99/100")
+			w.Header().Set("Content-Length", strconv.Itoa(100-from))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(body[from:]))
+			return
+		}
+		// the first response is chunked and breaks off halfway
+		w.Write([]byte(body[:50]))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		limit    int64
+		want     int64
+		requests int32
+		err      error
+	}{
+		{"resumed", 0, 100, 2, nil},
+		{"resumed within the limit", 100, 100, 2, nil},
+		{"resumed past the limit", 60, 50, 2, ErrResponseTooLarge},
+		{"limit hit before the interruption", 40, 40, 1, ErrResponseTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+			var buf bytes.Buffer
+			n, errs := New().SetMaxResponseBodySize(tt.limit).Get(srv.URL).Download(&buf)
+			if n != tt.want || int64(buf.Len()) != tt.want {
+				t.Fatalf("downloaded %d bytes, buffered %d, want %d", n, buf.Len(), tt.want)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.requests {
+				t.Fatalf("requests = %d, want %d", got, tt.requests)
+			}
+			if tt.err == nil && len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if tt.err != nil && (len(errs) == 0 || !errors.Is(errs[len(errs)-1], tt.err)) {
+				t.Fatalf("want %v, got %v", tt.err, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package httgo
+
+import (
+	"errors"
+	"net"
+)
+
+// Phases a request can fail in, every error returned by GetErrors and Err is an *Error
+// matching one of them with errors.Is
+var (
+	// ErrPhaseBuild covers client configuration and request construction
+	ErrPhaseBuild = errors.New("Build")
+	// ErrPhaseDial covers DNS resolution and connection establishment
+	ErrPhaseDial = errors.New("Dial")
+	// ErrPhaseSend covers writing the request and waiting for the response headers
+	ErrPhaseSend = errors.New("Send")
+	// ErrPhaseResponse covers unexpected responses such as *HTTPError
+	ErrPhaseResponse = errors.New("Response")
+	// ErrPhaseBody covers reading, decompressing and storing the response body
+	ErrPhaseBody = errors.New("Body")
+	// ErrPhaseDecode covers decoding the body into values or text
+	ErrPhaseDecode = errors.New("Decode")
+)
+
+// Error is a failure annotated with the phase it occurred in.
+// errors.Is and errors.As match both the phase and the underlying error.
+type Error struct {
+	Phase error
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Phase.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the phase and the underlying error
+func (e *Error) Unwrap() []error {
+	return []error{e.Phase, e.Err}
+}
+
+// Err returns every error of the request joined in order, nil when there is none
+func (b *RequestBuilder) Err() error {
+	return errors.Join(b.errs...)
+}
+
+// Err returns every configuration error of the client joined in order, nil when there is none
+func (c *HTTPClient) Err() error {
+	return errors.Join(c.errs...)
+}
+
+func (b *RequestBuilder) fail(phase, err error) {
+	b.errs = append(b.errs, wrapPhase(phase, b.phases.timeoutError(phase, err)))
+}
+
+func (c *HTTPClient) fail(phase, err error) {
+	c.errs = append(c.errs, wrapPhase(phase, err))
+}
+
+func wrapPhase(phase, err error) error {
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	return &Error{
+		Phase: phase,
+		Err:   err,
+	}
+}
+
+// transportPhase tells connection failures from failures of an established connection
+func transportPhase(err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrPhaseDial
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ErrPhaseDial
+	}
+	return ErrPhaseSend
+}
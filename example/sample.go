@@ -32,18 +32,21 @@ func main() {
 	var jsonBody map[string]interface{}
 	var xmlBody map[string]interface{}
 
-	errs = httgo.New().SetBasicAuth("user", "passowrd").
+	client := httgo.New().
 		EnableCache().
-		EnableRedirct().
-		SetRedirectCount(10).
-		Post("http://hogehoge/api/v1/foofoo").
+		EnableRedirect().
+		SetRedirectCount(10)
+
+	errs = client.Post("http://hogehoge/api/v1/foofoo").
+		SetBasicAuth("user", "passowrd").
 		DoWithContext(ctx).
 		JSON(&jsonBody).
-		ResetClient().
-		Get("http://foofoo/api/v1").
+		GetErrors()
+
+	errs = append(errs, client.Get("http://foofoo/api/v1").
 		Do().
 		XML(&xmlBody).
-		GetErrors()
+		GetErrors()...)
 
 	cancel()
 }
@@ -0,0 +1,55 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Exists is simple existence check using a new client
+func Exists(u string) (bool, error) {
+	return New().Exists(u)
+}
+
+// Exists reports whether u exists by sending HEAD, falling back to a GET of the
+// first byte when the server rejects HEAD. 404 and 410 mean false, as 416 to that GET means
+// an empty resource exists. Other non-2xx statuses are returned as *HTTPError.
+func (c *HTTPClient) Exists(u string) (bool, error) {
+	b := c.Head(u).Do()
+	status := b.StatusCode()
+	if err := requestError(b.Close()); err != nil {
+		return false, err
+	}
+
+	var ranged bool
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		ranged = true
+		b = c.Get(u).SetHeader("Range", []string{"bytes=0-0"}).Do()
+		status = b.StatusCode()
+		if err := requestError(b.Close()); err != nil {
+			return false, err
+		}
+	}
+
+	switch {
+	case status/100 == 2, ranged && status == http.StatusRequestedRangeNotSatisfiable:
+		return true, nil
+	case status == http.StatusNotFound, status == http.StatusGone:
+		return false, nil
+	}
+	return false, &HTTPError{
+		StatusCode: status,
+		Status:     b.Status(),
+		Header:     b.Header(),
+	}
+}
+
+// requestError returns the last error which is not an *HTTPError
+func requestError(errs []error) error {
+	for i := len(errs) - 1; i >= 0; i-- {
+		var herr *HTTPError
+		if !errors.As(errs[i], &herr) {
+			return errs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package httgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExists(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/gone":
+			w.WriteHeader(http.StatusGone)
+		case r.URL.Path == "/head" && r.Method == http.MethodHead:
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/empty":
+			// the empty resource has no byte 0
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte("x"))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		exists bool
+	}{
+		{"/head", true},
+		{"/get", true},
+		{"/empty", true},
+		{"/missing", false},
+		{"/gone", false},
+	}
+	for _, tt := range tests {
+		ok, err := New().Exists(srv.URL + tt.path)
+		if err != nil || ok != tt.exists {
+			t.Errorf("Exists(%s) = %v, %v, want %v", tt.path, ok, err, tt.exists)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package httgo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FetchOptions configures FetchAll
+type FetchOptions struct {
+	// Concurrency is the number of parallel requests, defaults to 8
+	Concurrency int
+	// PerHostInterval is the minimum delay between two requests to the same host
+	PerHostInterval time.Duration
+	// Retries is the number of retries after transport errors, timeouts, 429 and 5xx responses
+	Retries int
+	// RetryBackoff is the initial delay between retries, doubled on each attempt, defaults to 500ms
+	RetryBackoff time.Duration
+	// OnProgress is called after each URL completes
+	OnProgress func(done, total int)
+}
+
+// FetchResult is the outcome of fetching a single URL
+type FetchResult struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Attempts   int
+	Err        error
+}
+
+// FetchReport holds every result in input order and the failed subset
+type FetchReport struct {
+	Results []FetchResult
+	Failed  []FetchResult
+}
+
+// hostThrottle spaces requests to the same host at least interval apart
+type hostThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+const (
+	defaultFetchConcurrency = 8
+	defaultRetryBackoff     = 500 * time.Millisecond
+)
+
+// FetchAll GETs every URL with bounded concurrency, per-host throttling and retries
+func (c *HTTPClient) FetchAll(ctx context.Context, urls []string, opts FetchOptions) *FetchReport {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultFetchConcurrency
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+
+	report := &FetchReport{
+		Results: make([]FetchResult, len(urls)),
+	}
+
+	throttle := &hostThrottle{
+		interval: opts.PerHostInterval,
+		next:     make(map[string]time.Time),
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+
+	idx := make(chan int)
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				report.Results[i] = c.fetch(ctx, urls[i], opts, throttle)
+				if opts.OnProgress != nil {
+					mu.Lock()
+					done++
+					opts.OnProgress(done, len(urls))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range urls {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+
+	for _, r := range report.Results {
+		if r.Err != nil {
+			report.Failed = append(report.Failed, r)
+		}
+	}
+
+	return report
+}
+
+func (c *HTTPClient) fetch(ctx context.Context, u string, opts FetchOptions, throttle *hostThrottle) FetchResult {
+	r := FetchResult{
+		URL: u,
+	}
+
+	backoff := opts.RetryBackoff
+	for {
+		r.Attempts++
+		if r.Attempts > 1 {
+			atomic.AddUint64(&c.stats.retries, 1)
+		}
+
+		err := throttle.wait(ctx, u)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+
+		b := c.Get(u)
+		b.DoWithContext(WithAttempt(ctx, r.Attempts, opts.Retries+1))
+		r.StatusCode = b.StatusCode()
+		r.Header = b.Header()
+		if b.res != nil {
+			r.Body, err = ioutil.ReadAll(b.res.Body)
+			if err != nil {
+				b.fail(ErrPhaseBody, err)
+			}
+		}
+
+		errs := b.Close()
+		r.Err = nil
+		if len(errs) != 0 {
+			r.Err = errs[len(errs)-1]
+		} else if r.StatusCode >= 400 {
+			r.Err = &HTTPError{
+				StatusCode: r.StatusCode,
+				Status:     b.Status(),
+				Header:     r.Header,
+				Body:       r.Body,
+			}
+		}
+
+		if r.Err == nil || r.StatusCode != 0 && !retryableStatus(r.StatusCode) ||
+			r.StatusCode == 0 && !retryableFetchError(r.Err) {
+			return r
+		}
+
+		if r.Attempts > opts.Retries || ctx.Err() != nil {
+			return r
+		}
+
+		delay := backoff
+		if d, ok := retryAfter(r.Header); ok {
+			delay = d
+		}
+		backoff *= 2
+
+		select {
+		case <-ctx.Done():
+			r.Err = ctx.Err()
+			return r
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (t *hostThrottle) wait(ctx context.Context, u string) error {
+	if t.interval <= 0 {
+		return nil
+	}
+
+	host := u
+	if pu, err := url.Parse(u); err == nil {
+		host = pu.Host
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	t.next[host] = at.Add(t.interval)
+	t.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(at.Sub(now)):
+		return nil
+	}
+}
+
+// retryableFetchError reports transport and timeout failures, retries cannot fix
+// build errors or destinations refused by the client
+func retryableFetchError(err error) bool {
+	transport := errors.Is(err, ErrPhaseDial) || errors.Is(err, ErrPhaseSend) || errors.Is(err, ErrPhaseBody)
+	return transport && retryableAttempt(nil, err)
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchAllRetries(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if atomic.AddInt32(&hits, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		client   *HTTPClient
+		url      string
+		attempts int
+		ok       bool
+	}{
+		{"retryable status", New(), srv.URL + "/flaky", 2, true},
+		{"client error", New(), srv.URL + "/missing", 1, false},
+		{"transport error", New(), closed.URL, 3, false},
+		{"build error", New(), "http://[::1", 1, false},
+		{"blocked destination", New().EnableSSRFProtection(), srv.URL, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := tt.client.FetchAll(context.Background(), []string{tt.url}, FetchOptions{
+				Retries:      2,
+				RetryBackoff: time.Millisecond,
+			})
+			r := report.Results[0]
+			if r.Attempts != tt.attempts || (r.Err == nil) != tt.ok {
+				t.Fatalf("attempts = %d, err = %v, want %d attempts, ok %v", r.Attempts, r.Err, tt.attempts, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package httgo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SetFingerprintHeaders makes the request headers keys part of Fingerprint, and so of cache keys,
+// e.g. Accept or Accept-Language when the server varies its responses on them. Only the credentials are by default.
+func (c *HTTPClient) SetFingerprintHeaders(keys ...string) *HTTPClient {
+	c.fingerprintHeaders = make([]string, 0, len(keys))
+	for _, k := range keys {
+		c.fingerprintHeaders = append(c.fingerprintHeaders, http.CanonicalHeaderKey(k))
+	}
+	sort.Strings(c.fingerprintHeaders)
+	return c
+}
+
+// Fingerprint returns a stable SHA-256 hex digest identifying the request, building it if needed:
+// its method, normalized URL, its credentials, the headers of SetFingerprintHeaders and the digest of its body.
+// Credentials are the Authorization, Proxy-Authorization and Cookie headers, so responses cached for
+// one user are never served to another.
+// URLs are compared with lower-cased scheme and host, without default port, user info and fragment,
+// and with sorted query parameters. Bodies which cannot be replayed, see SetBodyStream, are left out.
+// The cache and the coalescing of concurrent misses identify requests by their fingerprint.
+func (b *RequestBuilder) Fingerprint() string {
+	b.newRequest()
+	if b.req == nil {
+		return ""
+	}
+	return b.client.fingerprint(b.req)
+}
+
+// credentialHeaders are always part of the fingerprint
+var credentialHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+func (c *HTTPClient) fingerprint(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.Method+"\n"+normalizeURL(req.URL)+"\n")
+	for _, k := range credentialHeaders {
+		if v, ok := req.Header[k]; ok {
+			io.WriteString(h, k+": "+strings.Join(v, ",")+"\n")
+		}
+	}
+	io.WriteString(h, "\n")
+	for _, k := range c.fingerprintHeaders {
+		if v, ok := req.Header[k]; ok {
+			io.WriteString(h, k+": "+strings.Join(v, ",")+"\n")
+		}
+	}
+	io.WriteString(h, "\n")
+	if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+		if body, err := req.GetBody(); err == nil {
+			bh := sha256.New()
+			io.Copy(bh, body)
+			body.Close()
+			h.Write(bh.Sum(nil))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheKey is the method, normalized URL and fingerprint of req, the first two let
+// OnCacheExpire hooks send the request again
+func (c *HTTPClient) cacheKey(req *http.Request) string {
+	return req.Method + " " + normalizeURL(req.URL) + " " + c.fingerprint(req)
+}
+
+// normalizeURL returns u with lower-cased scheme and host, without default port,
+// user info and fragment, and with query parameters sorted by key
+func normalizeURL(u *url.URL) string {
+	n := url.URL{
+		Scheme:   strings.ToLower(u.Scheme),
+		Host:     strings.ToLower(u.Host),
+		Path:     u.Path,
+		RawPath:  u.RawPath,
+		RawQuery: u.Query().Encode(),
+		Opaque:   u.Opaque,
+	}
+	switch {
+	case n.Scheme == "http" && strings.HasSuffix(n.Host, ":80"):
+		n.Host = strings.TrimSuffix(n.Host, ":80")
+	case n.Scheme == "https" && strings.HasSuffix(n.Host, ":443"):
+		n.Host = strings.TrimSuffix(n.Host, ":443")
+	}
+	if n.Path == "" && n.Opaque == "" {
+		n.Path = "/"
+	}
+	return n.String()
+}
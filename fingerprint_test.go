@@ -0,0 +1,35 @@
+package httgo
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	c := New()
+	base := c.Get("HTTP://Example.com:80/a?b=2&a=1#frag").Fingerprint()
+
+	tests := []struct {
+		name string
+		b    *RequestBuilder
+		same bool
+	}{
+		{"normalized URL", c.Get("http://example.com/a?a=1&b=2"), true},
+		{"other path", c.Get("http://example.com/b?a=1&b=2"), false},
+		{"other method", c.Head("http://example.com/a?a=1&b=2"), false},
+		{"unlisted header", c.Get("http://example.com/a?a=1&b=2").SetHeader("Accept", []string{"text/plain"}), true},
+		{"authorization", c.Get("http://example.com/a?a=1&b=2").SetHeader("Authorization", []string{"Bearer a"}), false},
+		{"proxy authorization", c.Get("http://example.com/a?a=1&b=2").SetHeader("Proxy-Authorization", []string{"Basic a"}), false},
+		{"cookie", c.Get("http://example.com/a?a=1&b=2").SetHeader("Cookie", []string{"session=a"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.b.Fingerprint() == base; got != tt.same {
+				t.Fatalf("same fingerprint = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	alice := c.Get("http://example.com/").SetHeader("Authorization", []string{"Bearer alice"}).Fingerprint()
+	bob := c.Get("http://example.com/").SetHeader("Authorization", []string{"Bearer bob"}).Fingerprint()
+	if alice == bob {
+		t.Fatal("requests of different users share a fingerprint")
+	}
+}
@@ -0,0 +1,114 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FlagProvider returns the feature flag and experiment assignments for a request,
+// e.g. backed by an experimentation platform SDK
+type FlagProvider interface {
+	Flags(ctx context.Context, req *http.Request) (map[string]string, error)
+}
+
+// FlagProviderFunc adapts a function to FlagProvider
+type FlagProviderFunc func(ctx context.Context, req *http.Request) (map[string]string, error)
+
+// FlagOptions configures SetFlagProvider
+type FlagOptions struct {
+	// HeaderPrefix is prepended to every flag name, defaults to "X-Feature-"
+	HeaderPrefix string
+	// CacheTTL is how long flags are reused for a cache key, zero asks the provider on every attempt
+	CacheTTL time.Duration
+	// CacheKey groups requests sharing the same assignments, defaults to the request host
+	CacheKey func(req *http.Request) string
+	// OnError receives provider errors, the request is sent with the last known flags if any
+	OnError func(req *http.Request, err error)
+}
+
+type flagInjector struct {
+	provider FlagProvider
+	opts     FlagOptions
+	mu       sync.Mutex
+	cache    map[string]flagEntry
+}
+
+type flagEntry struct {
+	flags   map[string]string
+	expires time.Time
+}
+
+const defaultFlagHeaderPrefix = "X-Feature-"
+
+// Flags calls f(ctx, req)
+func (f FlagProviderFunc) Flags(ctx context.Context, req *http.Request) (map[string]string, error) {
+	return f(ctx, req)
+}
+
+// SetFlagProvider injects the flags returned by p as headers into every attempt.
+// Headers already set on the request are left untouched.
+func (c *HTTPClient) SetFlagProvider(p FlagProvider, opts FlagOptions) *HTTPClient {
+	if opts.HeaderPrefix == "" {
+		opts.HeaderPrefix = defaultFlagHeaderPrefix
+	}
+	if opts.CacheKey == nil {
+		opts.CacheKey = func(req *http.Request) string {
+			return req.URL.Host
+		}
+	}
+
+	f := &flagInjector{
+		provider: p,
+		opts:     opts,
+		cache:    make(map[string]flagEntry),
+	}
+
+	return c.Use(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			flags := f.flags(req)
+			if len(flags) == 0 {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			for name, val := range flags {
+				key := http.CanonicalHeaderKey(opts.HeaderPrefix + name)
+				if _, ok := req.Header[key]; !ok {
+					req.Header.Set(key, val)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+func (f *flagInjector) flags(req *http.Request) map[string]string {
+	key := f.opts.CacheKey(req)
+
+	f.mu.Lock()
+	e, ok := f.cache[key]
+	f.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.flags
+	}
+
+	flags, err := f.provider.Flags(req.Context(), req)
+	if err != nil {
+		if f.opts.OnError != nil {
+			f.opts.OnError(req, err)
+		}
+		return e.flags
+	}
+
+	if f.opts.CacheTTL > 0 {
+		f.mu.Lock()
+		f.cache[key] = flagEntry{
+			flags:   flags,
+			expires: time.Now().Add(f.opts.CacheTTL),
+		}
+		f.mu.Unlock()
+	}
+	return flags
+}
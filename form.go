@@ -0,0 +1,311 @@
+package httgo
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrFormNotFound is returned by SubmitForm when no form of the response matches the selector
+var ErrFormNotFound = errors.New("Form Not Found")
+
+// htmlForm is a form parsed from an HTML page
+type htmlForm struct {
+	action string
+	method string
+	values url.Values
+}
+
+// formSelector is a simple CSS selector such as `form#login`, `.signin` or `form[action="/session"]`
+type formSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   map[string]string
+}
+
+// SubmitForm reads the HTML form of the response matching selector and returns the request submitting it,
+// not sent yet: the form action resolved against the response URL, its method, and every field the browser
+// would send, hidden inputs and CSRF tokens included, with fields overriding or adding values.
+// selector is a simple CSS selector such as "form#login", ".signin" or `form[action="/session"]`,
+// "" selects the first form. The jar's cookies are sent as usual, Referer and Origin are set to the page.
+// Forms are always sent URL encoded, file inputs are skipped.
+func (b *RequestBuilder) SubmitForm(selector string, fields map[string]string) *RequestBuilder {
+	c := b.client
+	res := b.response()
+	body, errs := b.GetByteBody()
+
+	failed := func(err error) *RequestBuilder {
+		nb := c.NewRequest(http.MethodGet, "")
+		nb.errs = append(nb.errs[:0], errs...)
+		if err != nil {
+			nb.fail(ErrPhaseDecode, err)
+		}
+		// there is nothing to send, Do returns nb as is
+		nb.isRequested = true
+		return nb
+	}
+	if res == nil || body == nil && len(errs) > 0 {
+		return failed(nil)
+	}
+
+	data, err := decodeCharset(body, detectCharset(body, res.Header.Get("Content-Type")))
+	if err != nil {
+		return failed(err)
+	}
+	form, ok := parseForm(string(data), parseSelector(selector))
+	if !ok {
+		return failed(ErrFormNotFound)
+	}
+	for k, v := range fields {
+		form.values.Set(k, v)
+	}
+
+	page := res.Request.URL
+	action, err := page.Parse(form.action)
+	if err != nil {
+		return failed(err)
+	}
+	action.Fragment = ""
+
+	nb := c.NewRequest(form.method, "")
+	if form.method == http.MethodGet {
+		action.RawQuery = form.values.Encode()
+	} else {
+		nb.SetContentType("application/x-www-form-urlencoded")
+		nb.SetBodyString(form.values.Encode())
+		nb.header.Set("Origin", page.Scheme+"://"+page.Host)
+	}
+	nb.url = action.String()
+	referer := *page
+	referer.User = nil
+	referer.Fragment = ""
+	nb.header.Set("Referer", referer.String())
+	return nb
+}
+
+// parseSelector parses the subset of CSS selectors SubmitForm supports
+func parseSelector(sel string) *formSelector {
+	s := &formSelector{
+		attrs: make(map[string]string),
+	}
+	sel = strings.TrimSpace(sel)
+	i := strings.IndexAny(sel, "#.[")
+	if i < 0 {
+		i = len(sel)
+	}
+	s.tag = strings.ToLower(sel[:i])
+	sel = sel[i:]
+	for len(sel) > 0 {
+		switch sel[0] {
+		case '[':
+			end := strings.IndexByte(sel, ']')
+			if end < 0 {
+				end = len(sel)
+			}
+			attr := sel[1:end]
+			if eq := strings.IndexByte(attr, '='); eq >= 0 {
+				s.attrs[strings.ToLower(strings.TrimSpace(attr[:eq]))] = strings.Trim(strings.TrimSpace(attr[eq+1:]), `"'`)
+			} else {
+				s.attrs[strings.ToLower(strings.TrimSpace(attr))] = "\x00"
+			}
+			if end < len(sel) {
+				end++
+			}
+			sel = sel[end:]
+		default:
+			end := strings.IndexAny(sel[1:], "#.[")
+			if end < 0 {
+				end = len(sel) - 1
+			}
+			if sel[0] == '#' {
+				s.id = sel[1 : end+1]
+			} else {
+				s.classes = append(s.classes, sel[1:end+1])
+			}
+			sel = sel[end+1:]
+		}
+	}
+	return s
+}
+
+// match reports whether the element name with attrs matches s
+func (s *formSelector) match(name string, attrs map[string]string) bool {
+	if s.tag != "" && s.tag != name || s.id != "" && attrs["id"] != s.id {
+		return false
+	}
+	classes := strings.Fields(attrs["class"])
+	for _, want := range s.classes {
+		found := false
+		for _, cl := range classes {
+			found = found || cl == want
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, want := range s.attrs {
+		v, ok := attrs[k]
+		if !ok || want != "\x00" && v != want {
+			return false
+		}
+	}
+	return true
+}
+
+// parseForm returns the first form of page matching sel, it is tolerant of malformed documents
+func parseForm(page string, sel *formSelector) (*htmlForm, bool) {
+	var form *htmlForm
+	var selectName, selectFirst string
+	var selected bool
+	for len(page) > 0 {
+		i := strings.IndexByte(page, '<')
+		if i < 0 {
+			break
+		}
+		page = page[i:]
+		if strings.HasPrefix(page, "<!--") {
+			end := strings.Index(page, "-->")
+			if end < 0 {
+				break
+			}
+			page = page[end+3:]
+			continue
+		}
+		end := tagEnd(page)
+		if end < 0 {
+			break
+		}
+		tag := page[1:end]
+		page = page[end+1:]
+
+		name, attrs, closing := parseTag(tag)
+		switch {
+		case name == "script" || name == "style":
+			if !closing && !strings.HasSuffix(tag, "/") {
+				page = skipElement(page, name)
+			}
+		case name == "form" && form == nil && !closing:
+			if !sel.match(name, attrs) {
+				continue
+			}
+			form = &htmlForm{
+				action: strings.TrimSpace(attrs["action"]),
+				method: strings.ToUpper(strings.TrimSpace(attrs["method"])),
+				values: make(url.Values),
+			}
+			if form.method != http.MethodPost {
+				form.method = http.MethodGet
+			}
+		case form == nil:
+		case name == "form" && closing:
+			return form, true
+		case closing:
+			if name == "select" && selectName != "" && !selected && selectFirst != "\x00" {
+				form.values.Add(selectName, selectFirst)
+			}
+			if name == "select" {
+				selectName = ""
+			}
+		case attrs["name"] == "" && name != "option", hasAttr(attrs, "disabled"):
+		case name == "input":
+			switch strings.ToLower(attrs["type"]) {
+			case "submit", "button", "image", "reset", "file":
+			case "checkbox", "radio":
+				if hasAttr(attrs, "checked") {
+					v, ok := attrs["value"]
+					if !ok {
+						v = "on"
+					}
+					form.values.Add(attrs["name"], v)
+				}
+			default:
+				form.values.Add(attrs["name"], attrs["value"])
+			}
+		case name == "textarea":
+			text := page
+			if i := strings.Index(strings.ToLower(page), "</textarea"); i >= 0 {
+				text = page[:i]
+			}
+			form.values.Add(attrs["name"], html.UnescapeString(strings.TrimPrefix(text, "\n")))
+			page = skipElement(page, "textarea")
+		case name == "select":
+			selectName, selectFirst, selected = attrs["name"], "\x00", false
+		case name == "option" && selectName != "":
+			v, ok := attrs["value"]
+			if !ok {
+				text := page
+				if i := strings.IndexByte(page, '<'); i >= 0 {
+					text = page[:i]
+				}
+				v = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+			}
+			if selectFirst == "\x00" {
+				selectFirst = v
+			}
+			if hasAttr(attrs, "selected") && !selected {
+				selected = true
+				form.values.Add(selectName, v)
+			}
+		}
+	}
+	return form, form != nil
+}
+
+// parseTag splits the content of a tag into its lower-cased name and unescaped attributes
+func parseTag(tag string) (string, map[string]string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimSuffix(strings.TrimLeft(tag, "/"), "/")
+	n := strings.IndexAny(tag, " \t\r\n")
+	if n < 0 {
+		return strings.ToLower(tag), nil, closing
+	}
+	name, rest := strings.ToLower(tag[:n]), tag[n:]
+
+	attrs := make(map[string]string)
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n/")
+		if rest == "" {
+			return name, attrs, closing
+		}
+		n := strings.IndexAny(rest, "= \t\r\n")
+		if n < 0 {
+			n = len(rest)
+		}
+		key := strings.ToLower(rest[:n])
+		rest = strings.TrimLeft(rest[n:], " \t\r\n")
+		if !strings.HasPrefix(rest, "=") {
+			if _, ok := attrs[key]; !ok {
+				attrs[key] = ""
+			}
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+
+		var v string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				v, rest = rest[1:], ""
+			} else {
+				v, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\r\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			v, rest = rest[:end], rest[end:]
+		}
+		if _, ok := attrs[key]; !ok {
+			attrs[key] = html.UnescapeString(v)
+		}
+	}
+}
+
+func hasAttr(attrs map[string]string, key string) bool {
+	_, ok := attrs[key]
+	return ok
+}
@@ -0,0 +1,110 @@
+package httgo
+
+import (
+	"context"
+	"sync"
+)
+
+// Group sends requests concurrently errgroup-style: the first fatal request
+// cancels the ones still in flight or not started yet
+type Group struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	builders []*RequestBuilder
+	err      error
+	fatal    func(b *RequestBuilder) bool
+}
+
+// Group returns a Group whose requests are sent with ctx. By default a request
+// is fatal when it has any error, see FailOnHTTPError to include error statuses.
+func (c *HTTPClient) Group(ctx context.Context) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		fatal: func(b *RequestBuilder) bool {
+			return len(b.errs) != 0
+		},
+	}
+}
+
+// SetLimit bounds the requests in flight to n, Go blocks while the limit is reached.
+// It must be called before the first Go.
+func (g *Group) SetLimit(n int) *Group {
+	if n > 0 {
+		g.sem = make(chan struct{}, n)
+	}
+	return g
+}
+
+// SetFatal decides which requests cancel the group, nil disables cancellation
+func (g *Group) SetFatal(fn func(b *RequestBuilder) bool) *Group {
+	g.fatal = fn
+	return g
+}
+
+// Go sends b in a new goroutine. Requests added after the group was canceled fail with its context error.
+func (g *Group) Go(b *RequestBuilder) *Group {
+	return g.GoJSON(b, nil)
+}
+
+// GoJSON sends b and decodes its JSON body into v in the same goroutine, a decoding error is fatal too
+func (g *Group) GoJSON(b *RequestBuilder, v interface{}) *Group {
+	g.mu.Lock()
+	g.builders = append(g.builders, b)
+	g.mu.Unlock()
+
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			b.isRequested = true
+			b.fail(ErrPhaseSend, g.ctx.Err())
+			return g
+		}
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() {
+				<-g.sem
+			}()
+		}
+
+		if err := g.ctx.Err(); err != nil {
+			b.isRequested = true
+			b.fail(ErrPhaseSend, err)
+			return
+		}
+
+		b.DoWithContext(g.ctx)
+		if v != nil {
+			b.JSON(v)
+		}
+
+		if g.fatal != nil && g.fatal(b) {
+			g.mu.Lock()
+			if g.err == nil {
+				g.err = b.Err()
+				g.cancel()
+			}
+			g.mu.Unlock()
+		}
+	}()
+	return g
+}
+
+// Wait waits for every request and returns them in the order they were added,
+// with the error of the first fatal request. Unlike errgroup the context is not
+// canceled on success, so the responses can still be read.
+func (g *Group) Wait() ([]*RequestBuilder, error) {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.builders, g.err
+}
@@ -0,0 +1,362 @@
+package httgo
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// harRecorder keeps a HAR 1.2 log of every attempt and rewrites the file after each entry
+type harRecorder struct {
+	path   string
+	client *HTTPClient
+	mu     sync.Mutex
+	log    harLog
+}
+
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harTimings are in milliseconds, -1 when the phase did not happen, e.g. on reused connections
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harTrace collects the phase timestamps of one attempt
+type harTrace struct {
+	start, dnsStart, dnsDone, connStart, connDone, tlsStart, tlsDone, wrote, firstByte time.Time
+}
+
+type harBody struct {
+	io.ReadCloser
+	h     *harRecorder
+	entry *harEntry
+	trace *harTrace
+	buf   bytes.Buffer
+	done  bool
+}
+
+// EnableHAR logs every attempt, including redirect hops, to a HAR 1.2 file at path with
+// timings, headers and bodies, e.g. to load a session into browser devtools.
+// The file is rewritten after each completed response; credentials and the body fields of SetRedactionPaths are masked.
+func (c *HTTPClient) EnableHAR(path string) *HTTPClient {
+	h := &harRecorder{
+		path:   path,
+		client: c,
+	}
+	h.log.Log.Version = "1.2"
+	h.log.Log.Creator = harCreator{
+		Name:    "httgo",
+		Version: "1",
+	}
+	h.log.Log.Entries = []harEntry{}
+	return c.Use(h.middleware)
+}
+
+func (h *harRecorder) middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		t := &harTrace{
+			start: time.Now(),
+		}
+		entry := &harEntry{
+			StartedDateTime: t.start.Format(time.RFC3339Nano),
+			Request:         h.requestOf(req),
+		}
+
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace()))
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			entry.Comment = err.Error()
+			h.add(entry, t, nil)
+			return res, err
+		}
+
+		entry.Response = harResponse{
+			Status:      res.StatusCode,
+			StatusText:  http.StatusText(res.StatusCode),
+			HTTPVersion: res.Proto,
+			Cookies:     harCookies(res.Cookies()),
+			Headers:     harHeaders(res.Header),
+			Content: harContent{
+				MimeType: res.Header.Get("Content-Type"),
+			},
+			RedirectURL: res.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		if res.Body == nil || res.StatusCode == http.StatusSwitchingProtocols {
+			h.add(entry, t, nil)
+			return res, nil
+		}
+
+		res.Body = &harBody{
+			ReadCloser: res.Body,
+			h:          h,
+			entry:      entry,
+			trace:      t,
+		}
+		return res, nil
+	})
+}
+
+func (h *harRecorder) requestOf(req *http.Request) harRequest {
+	hr := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     harCookies(req.Cookies()),
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	for k, vs := range req.URL.Query() {
+		for _, v := range vs {
+			hr.QueryString = append(hr.QueryString, harNameValue{
+				Name:  k,
+				Value: v,
+			})
+		}
+	}
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			hr.BodySize = len(body)
+			hr.PostData = &harPostData{
+				MimeType: req.Header.Get("Content-Type"),
+				Text:     string(h.client.redactBody(body)),
+			}
+		}
+	}
+	return hr
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	list := []harNameValue{}
+	for k, vs := range maskHeader(h) {
+		for _, v := range vs {
+			list = append(list, harNameValue{
+				Name:  k,
+				Value: v,
+			})
+		}
+	}
+	return list
+}
+
+func harCookies(cookies []*http.Cookie) []harNameValue {
+	list := []harNameValue{}
+	for _, c := range cookies {
+		list = append(list, harNameValue{
+			Name:  c.Name,
+			Value: c.Value,
+		})
+	}
+	return list
+}
+
+func (t *harTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.dnsDone = time.Now()
+		},
+		ConnectStart: func(string, string) {
+			if t.connStart.IsZero() {
+				t.connStart = time.Now()
+			}
+		},
+		ConnectDone: func(string, string, error) {
+			t.connDone = time.Now()
+		},
+		TLSHandshakeStart: func() {
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.tlsDone = time.Now()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.wrote = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			t.firstByte = time.Now()
+		},
+	}
+}
+
+func (t *harTrace) timings(end time.Time) harTimings {
+	ms := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return float64(to.Sub(from)) / float64(time.Millisecond)
+	}
+	tm := harTimings{
+		Blocked: -1,
+		DNS:     ms(t.dnsStart, t.dnsDone),
+		Connect: ms(t.connStart, t.connDone),
+		SSL:     ms(t.tlsStart, t.tlsDone),
+		Send:    0,
+		Wait:    ms(t.wrote, t.firstByte),
+		Receive: ms(t.firstByte, end),
+	}
+	if tm.Wait < 0 {
+		tm.Wait = 0
+	}
+	if tm.Receive < 0 {
+		tm.Receive = 0
+	}
+	return tm
+}
+
+func (hb *harBody) Read(p []byte) (int, error) {
+	n, err := hb.ReadCloser.Read(p)
+	hb.buf.Write(p[:n])
+	if err == io.EOF {
+		hb.finish()
+	}
+	return n, err
+}
+
+func (hb *harBody) Close() error {
+	hb.finish()
+	return hb.ReadCloser.Close()
+}
+
+func (hb *harBody) finish() {
+	if hb.done {
+		return
+	}
+	hb.done = true
+	hb.h.add(hb.entry, hb.trace, hb.buf.Bytes())
+}
+
+func (h *harRecorder) add(entry *harEntry, t *harTrace, body []byte) {
+	end := time.Now()
+	entry.Time = float64(end.Sub(t.start)) / float64(time.Millisecond)
+	entry.Timings = t.timings(end)
+	entry.Response.Content.Size = len(body)
+	body = h.client.redactBody(body)
+	if utf8.Valid(body) {
+		entry.Response.Content.Text = string(body)
+	} else {
+		entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+		entry.Response.Content.Encoding = "base64"
+	}
+	if entry.Response.HTTPVersion == "" {
+		entry.Response.HTTPVersion = "unknown"
+		entry.Response.Cookies = []harNameValue{}
+		entry.Response.Headers = []harNameValue{}
+		entry.Response.HeadersSize = -1
+		entry.Response.BodySize = -1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.log.Log.Entries = append(h.log.Log.Entries, *entry)
+	err := h.write()
+	if err != nil && h.client.logger != nil {
+		h.client.logger.Printf("har: %v", err)
+	}
+}
+
+// write replaces the file atomically so it is always a valid HAR document
+func (h *harRecorder) write() error {
+	data, err := json.MarshalIndent(h.log, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(h.path), ".har-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
@@ -0,0 +1,107 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// InvalidHeaderError is returned for request headers which could be used for
+// header injection or request smuggling, Key is the offending header
+type InvalidHeaderError struct {
+	Key    string
+	Reason string
+}
+
+var (
+	// ErrInvalidHeader matches *InvalidHeaderError with errors.Is
+	ErrInvalidHeader = errors.New("Invalid Request Header")
+)
+
+func (e *InvalidHeaderError) Error() string {
+	return ErrInvalidHeader.Error() + " " + strconv.Quote(e.Key) + ": " + e.Reason
+}
+
+// Unwrap returns ErrInvalidHeader
+func (e *InvalidHeaderError) Unwrap() error {
+	return ErrInvalidHeader
+}
+
+// validateHeader rejects CR/LF injection, invalid field names, duplicate or
+// malformed Content-Length and Transfer-Encoding conflicting with it,
+// header values often come from user input
+func validateHeader(req *http.Request) error {
+	if strings.ContainsAny(req.Host, "\r\n\x00") {
+		return &InvalidHeaderError{
+			Key:    "Host",
+			Reason: "control character in value",
+		}
+	}
+
+	for k, vs := range req.Header {
+		if !validFieldName(k) {
+			return &InvalidHeaderError{
+				Key:    k,
+				Reason: "invalid field name",
+			}
+		}
+		for _, v := range vs {
+			if strings.ContainsAny(v, "\r\n\x00") {
+				return &InvalidHeaderError{
+					Key:    k,
+					Reason: "control character in value",
+				}
+			}
+		}
+	}
+
+	cl := req.Header.Values("Content-Length")
+	if len(cl) > 1 || len(cl) == 1 && strings.Contains(cl[0], ",") {
+		return &InvalidHeaderError{
+			Key:    "Content-Length",
+			Reason: "multiple values",
+		}
+	}
+	if len(cl) == 1 {
+		n, err := strconv.ParseInt(strings.TrimSpace(cl[0]), 10, 64)
+		if err != nil || n < 0 {
+			return &InvalidHeaderError{
+				Key:    "Content-Length",
+				Reason: "invalid value",
+			}
+		}
+	}
+
+	te := req.Header.Values("Transfer-Encoding")
+	if len(te) == 0 {
+		return nil
+	}
+	if len(cl) != 0 {
+		return &InvalidHeaderError{
+			Key:    "Transfer-Encoding",
+			Reason: "conflicts with Content-Length",
+		}
+	}
+	if len(te) > 1 || !strings.EqualFold(strings.TrimSpace(te[0]), "chunked") {
+		return &InvalidHeaderError{
+			Key:    "Transfer-Encoding",
+			Reason: "only a single chunked coding is allowed",
+		}
+	}
+	return nil
+}
+
+// validFieldName reports whether k is an RFC 7230 token
+func validFieldName(k string) bool {
+	if k == "" {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
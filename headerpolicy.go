@@ -0,0 +1,89 @@
+package httgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SensitiveHeaderPolicy controls which headers are dropped before a request
+// leaves the origin it was built for
+type SensitiveHeaderPolicy struct {
+	// Headers are dropped on cross-origin redirects, defaults to Authorization and Cookie
+	Headers []string
+	// AllowedHosts lists per header the hosts it may still be sent to across origins,
+	// a "*." prefix matches every subdomain, e.g. {"Authorization": {"*.example.com"}}
+	AllowedHosts map[string][]string
+	// StripOnProxy drops Headers from plain HTTP requests sent through a proxy, which can read them
+	StripOnProxy bool
+	// Strip, when set, decides for every header in Headers instead of AllowedHosts
+	Strip func(header string, req *http.Request) bool
+}
+
+// SetSensitiveHeaderPolicy replaces the rules stripping credentials on cross-origin redirects
+// and proxied requests. Headers allowed for a destination are copied from the original request
+// even when net/http dropped them, except Cookie, which the jar sets per destination.
+func (c *HTTPClient) SetSensitiveHeaderPolicy(p SensitiveHeaderPolicy) *HTTPClient {
+	if len(p.Headers) == 0 {
+		p.Headers = []string{"Authorization", "Cookie"}
+	}
+	allowed := make(map[string][]string, len(p.AllowedHosts))
+	for k, hosts := range p.AllowedHosts {
+		allowed[http.CanonicalHeaderKey(k)] = hosts
+	}
+	p.AllowedHosts = allowed
+	c.headerPolicy = &p
+	return c
+}
+
+// strip reports whether header must not be sent with req
+func (p *SensitiveHeaderPolicy) strip(header string, req *http.Request) bool {
+	if p.Strip != nil {
+		return p.Strip(header, req)
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	for _, pattern := range p.AllowedHosts[http.CanonicalHeaderKey(header)] {
+		pattern = strings.ToLower(pattern)
+		if pattern == host || strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// redirect applies the policy to a cross-origin redirect of orig
+func (p *SensitiveHeaderPolicy) redirect(req, orig *http.Request) {
+	for _, k := range p.Headers {
+		if p.strip(k, req) {
+			req.Header.Del(k)
+			continue
+		}
+		if http.CanonicalHeaderKey(k) != "Cookie" && req.Header.Get(k) == "" {
+			if v, ok := orig.Header[http.CanonicalHeaderKey(k)]; ok {
+				req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+			}
+		}
+	}
+}
+
+// stripProxied strips the policy headers from a plain HTTP request going through a proxy
+func (c *HTTPClient) stripProxied(req *http.Request) *http.Request {
+	p := c.headerPolicy
+	if p == nil || !p.StripOnProxy || req.URL.Scheme != "http" {
+		return req
+	}
+	if c.proxyFor(req) == nil {
+		return req
+	}
+	cloned := false
+	for _, k := range p.Headers {
+		if req.Header.Get(k) == "" || !p.strip(k, req) {
+			continue
+		}
+		if !cloned {
+			req = req.Clone(req.Context())
+			cloned = true
+		}
+		req.Header.Del(k)
+	}
+	return req
+}
@@ -0,0 +1,77 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HeaderProvider resolves a header value, e.g. a secret from Vault or KMS
+type HeaderProvider func(ctx context.Context) (string, error)
+
+// headerProvider caches the value of a HeaderProvider for the client's header provider TTL
+type headerProvider struct {
+	key     string
+	fn      HeaderProvider
+	mu      sync.Mutex
+	value   string
+	expires time.Time
+}
+
+const defaultHeaderProviderTTL = 5 * time.Minute
+
+// SetHeaderFromProvider sets the header key of every request to the value of provider,
+// resolved lazily with the request context right before sending and cached for the
+// header provider TTL. Headers set on the request itself take precedence.
+func (c *HTTPClient) SetHeaderFromProvider(key string, provider HeaderProvider) *HTTPClient {
+	c.headerProviders = append(c.headerProviders, &headerProvider{
+		key: http.CanonicalHeaderKey(key),
+		fn:  provider,
+	})
+	return c
+}
+
+// SetHeaderProviderTTL sets how long provider values are cached, 5 minutes by default.
+// A negative ttl resolves them for every request.
+func (c *HTTPClient) SetHeaderProviderTTL(ttl time.Duration) *HTTPClient {
+	c.headerProviderTTL = ttl
+	return c
+}
+
+func (b *RequestBuilder) applyHeaderProviders() error {
+	c := b.client
+	ttl := c.headerProviderTTL
+	if ttl == 0 {
+		ttl = defaultHeaderProviderTTL
+	}
+	for _, p := range c.headerProviders {
+		if b.req.Header.Get(p.key) != "" {
+			continue
+		}
+		v, err := p.get(b.req.Context(), ttl)
+		if err != nil {
+			return err
+		}
+		b.req.Header.Set(p.key, v)
+	}
+	return nil
+}
+
+// get returns the cached value or resolves it, concurrent callers wait for a single resolution
+func (p *headerProvider) get(ctx context.Context, ttl time.Duration) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Now().Before(p.expires) {
+		return p.value, nil
+	}
+	v, err := p.fn(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.value = v
+	if ttl > 0 {
+		p.expires = time.Now().Add(ttl)
+	}
+	return v, nil
+}
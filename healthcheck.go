@@ -0,0 +1,200 @@
+package httgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthTarget describes an endpoint probed by a HealthChecker
+type HealthTarget struct {
+	// Name identifies the target, the URL is used when empty
+	Name string
+	URL  string
+	// Method defaults to GET
+	Method string
+	// ExpectedStatus defaults to any 2xx status
+	ExpectedStatus int
+	// LatencyBudget marks the target unhealthy when exceeded, zero disables it
+	LatencyBudget time.Duration
+	// Interval defaults to 10 seconds
+	Interval time.Duration
+	// Timeout defaults to the Interval
+	Timeout time.Duration
+}
+
+// HealthStatus is the result of the latest probe of a target
+type HealthStatus struct {
+	Name       string
+	Healthy    bool
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+	CheckedAt  time.Time
+}
+
+// HealthChangeFunc is called when a target flips between healthy and unhealthy
+type HealthChangeFunc func(prev, cur HealthStatus)
+
+// HealthChecker periodically probes a set of targets
+type HealthChecker struct {
+	client   *HTTPClient
+	targets  []HealthTarget
+	mu       sync.RWMutex
+	status   map[string]HealthStatus
+	onChange []HealthChangeFunc
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+const defaultHealthInterval = 10 * time.Second
+
+var (
+	// ErrUnexpectedStatus is reported when a probe receives an unexpected status code
+	ErrUnexpectedStatus = errors.New("Unexpected Status Code")
+	// ErrLatencyBudgetExceeded is reported when a probe is slower than its budget
+	ErrLatencyBudgetExceeded = errors.New("Latency Budget Exceeded")
+)
+
+// HealthCheck returns a HealthChecker probing targets with this client, call Start to run it
+func (c *HTTPClient) HealthCheck(targets []HealthTarget) *HealthChecker {
+	h := &HealthChecker{
+		client:  c,
+		targets: make([]HealthTarget, len(targets)),
+		status:  make(map[string]HealthStatus, len(targets)),
+	}
+	for i, t := range targets {
+		if t.Name == "" {
+			t.Name = t.URL
+		}
+		if t.Method == "" {
+			t.Method = http.MethodGet
+		}
+		if t.Interval <= 0 {
+			t.Interval = defaultHealthInterval
+		}
+		if t.Timeout <= 0 {
+			t.Timeout = t.Interval
+		}
+		h.targets[i] = t
+	}
+	return h
+}
+
+// OnChange registers a callback invoked when a target changes health
+func (h *HealthChecker) OnChange(fn HealthChangeFunc) *HealthChecker {
+	h.mu.Lock()
+	h.onChange = append(h.onChange, fn)
+	h.mu.Unlock()
+	return h
+}
+
+// Start probes every target immediately and then at its interval until ctx is done or Stop is called
+func (h *HealthChecker) Start(ctx context.Context) *HealthChecker {
+	ctx, h.cancel = context.WithCancel(ctx)
+	for _, t := range h.targets {
+		h.wg.Add(1)
+		go func(t HealthTarget) {
+			defer h.wg.Done()
+			ticker := time.NewTicker(t.Interval)
+			defer ticker.Stop()
+			for {
+				h.update(h.probe(ctx, t))
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(t)
+	}
+	return h
+}
+
+// Stop stops probing and waits for in-flight probes
+func (h *HealthChecker) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.wg.Wait()
+}
+
+// Status returns the latest status of every probed target
+func (h *HealthChecker) Status() []HealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	list := make([]HealthStatus, 0, len(h.targets))
+	for _, t := range h.targets {
+		if st, ok := h.status[t.Name]; ok {
+			list = append(list, st)
+		}
+	}
+	return list
+}
+
+// StatusOf returns the latest status of the named target
+func (h *HealthChecker) StatusOf(name string) (HealthStatus, bool) {
+	h.mu.RLock()
+	st, ok := h.status[name]
+	h.mu.RUnlock()
+	return st, ok
+}
+
+// Healthy reports whether every target has been probed and is healthy
+func (h *HealthChecker) Healthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, t := range h.targets {
+		st, ok := h.status[t.Name]
+		if !ok || !st.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *HealthChecker) probe(ctx context.Context, t HealthTarget) HealthStatus {
+	st := HealthStatus{
+		Name: t.Name,
+	}
+
+	start := time.Now()
+	b := h.client.NewRequest(t.Method, t.URL).
+		SetRequestTimeout(t.Timeout).
+		DoWithContext(ctx)
+	st.Latency = time.Since(start)
+	st.CheckedAt = start
+	st.StatusCode = b.StatusCode()
+	errs := b.Close()
+
+	switch {
+	case len(errs) != 0:
+		st.Err = errs[len(errs)-1]
+	case t.ExpectedStatus != 0 && st.StatusCode != t.ExpectedStatus,
+		t.ExpectedStatus == 0 && st.StatusCode/100 != 2:
+		st.Err = ErrUnexpectedStatus
+	case t.LatencyBudget > 0 && st.Latency > t.LatencyBudget:
+		st.Err = ErrLatencyBudgetExceeded
+	default:
+		st.Healthy = true
+	}
+
+	return st
+}
+
+func (h *HealthChecker) update(st HealthStatus) {
+	h.mu.Lock()
+	prev, ok := h.status[st.Name]
+	h.status[st.Name] = st
+	fns := h.onChange
+	h.mu.Unlock()
+
+	if ok && prev.Healthy == st.Healthy {
+		return
+	}
+	for _, fn := range fns {
+		fn(prev, st)
+	}
+}
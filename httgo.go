@@ -1,49 +1,90 @@
 package httgo
 
 import (
-	"bytes"
-	"compress/gzip"
-	"context"
 	"crypto/tls"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
-	"io"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
-
-	"github.com/kpango/gache"
 )
 
+// HTTPClient holds the configuration shared by every request it builds.
+// Once configured it is safe for concurrent use by multiple goroutines.
 type HTTPClient struct {
-	cacheEnabled    bool
-	errors          []error
-	maxRedirect     int
-	redirectEnabled bool
-	userAgent       string
-	client          *http.Client
-	transport       *http.Transport
-	cjar            *cookiejar.Jar
-	request         *Request
-	res             *http.Response
-	errs            []error
-}
-
-type Request struct {
-	req            *http.Request
-	header         http.Header
-	body           io.Reader
-	method         string
-	url            string
-	basic          *BasicAuth
-	isRequestReady bool
-	isRequested    bool
+	cacheEnabled         bool
+	cache                CacheStore
+	cacheStatusHeader    bool
+	expiry               *cacheExpiry
+	flights              *cacheFlights
+	maxRedirect          int
+	redirectEnabled      bool
+	redirectStripHeaders []string
+	redirectPolicy       RedirectPolicy
+	redirectMethods      map[string]bool
+	headerPolicy         *SensitiveHeaderPolicy
+	bodyReadTimeout      time.Duration
+	maxResponseBodySize  int64
+	lenientJSON          bool
+	jsonUseNumber        bool
+	jsonDisallowUnknown  bool
+	jsonMarshal          JSONMarshalFunc
+	jsonUnmarshal        JSONUnmarshalFunc
+	failOnHTTPError      bool
+	errorDecoder         ErrorDecoder
+	userAgent            string
+	header               http.Header
+	acceptEncoding       string
+	zstdDicts            map[string][]byte
+	baseURL              string
+	hostPool             *hostPool
+	rewriteRules         []*rewriteRule
+	split                *trafficSplit
+	fingerprintHeaders   []string
+	tokenSource          TokenFunc
+	headerProviders      []*headerProvider
+	headerProviderTTL    time.Duration
+	signer               Signer
+	proxyAuth            *proxyAuth
+	client               *http.Client
+	transport            *http.Transport
+	transportMu          sync.RWMutex
+	http3                http.RoundTripper
+	h2c                  *h2cTransport
+	stub                 http.RoundTripper
+	dialer               *net.Dialer
+	dialContextFunc      DialContextFunc
+	dns                  *dnsCache
+	resolver             *net.Resolver
+	ssrfProtection       bool
+	cjar                 *cookiejar.Jar
+	traceEnabled         bool
+	strict               bool
+	rawHeaders           bool
+	unsafeKeyLog         bool
+	middlewares          []Middleware
+	handler              http.RoundTripper
+	archiver             *archiver
+	archiveMu            sync.Mutex
+	metrics              MetricsCollector
+	urlTemplate          func(*http.Request) string
+	drift                *schemaDrift
+	stats                *clientStats
+	attemptHeader        bool
+	expectContinue       bool
+	limiter              *rateLimiter
+	breakers             *circuitBreakers
+	retries              int
+	retryBackoff         time.Duration
+	idempotency          bool
+	hostLimiters         *hostLimiters
+	rateStore            *rateLimitStore
+	logger               Logger
+	debugBodyLimit       int
+	redactPaths          [][]string
+	errs                 []error
 }
 
 type BasicAuth struct {
@@ -60,6 +101,7 @@ var (
 	ErrInvalidURL              = errors.New("Invalid URL")
 	ErrInvalidRedirectLocation = errors.New("Invalid Redirect Location")
 	ErrTooManyRedirection      = errors.New("Too many Redirect")
+	ErrBodyReadTimeout         = errors.New("Response Body Read Timeout")
 )
 
 // Get Singleton Client
@@ -67,169 +109,133 @@ func GetHTTPClient() *HTTPClient {
 	once.Do(func() {
 		client = New()
 	})
-	client.request = new(Request)
-	client.maxRedirect = 0
 	return client
 }
 
 // New Generates HTTPClient instance
 func New() *HTTPClient {
+	c := new(HTTPClient)
+	c.init()
+	return c
+}
+
+// init sets up c as a new client, the transport and redirect hooks refer to c
+func (c *HTTPClient) init() {
 	jar, err := cookiejar.New(&cookiejar.Options{})
 
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
 		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
+			MinVersion: tls.VersionTLS12,
 		},
 	}
 
-	client := &HTTPClient{
-		client: &http.Client{
-			Jar:       jar,
-			Transport: transport,
-		},
-		transport: transport,
-		cjar:      jar,
-		request: &Request{
-			method:         http.MethodGet,
-			isRequestReady: false,
-			isRequested:    false,
-		},
-		maxRedirect:  0,
-		cacheEnabled: false,
+	c.client = &http.Client{
+		Jar: jar,
 	}
+	c.transport = transport
+	c.dialer = dialer
+	c.cjar = jar
+	c.stats = newClientStats()
+	c.flights = newCacheFlights()
+	c.maxRedirect = defaultMaxRedirect
+	c.redirectEnabled = true
+	c.cacheEnabled = false
 
-	if err != nil {
-		client.errs[0] = err
+	c.client.CheckRedirect = c.checkRedirect
+	c.transport.DialContext = c.dialContext
+	c.client.Transport = &roundTripper{
+		client: c,
 	}
 
-	return client
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+	}
 }
 
 // Get is simple GetRequest Builder
-func Get(u string) *HTTPClient {
+func Get(u string) *RequestBuilder {
 	return New().Get(u)
 }
 
 // Post is simple PostRequest Builder
-func Post(u string) *HTTPClient {
+func Post(u string) *RequestBuilder {
 	return New().Post(u)
 }
 
 // Put is simple PutRequest Builder
-func Put(u string) *HTTPClient {
+func Put(u string) *RequestBuilder {
 	return New().Put(u)
 }
 
 // Patch is simple PutRequest Builder
-func Patch(u string) *HTTPClient {
+func Patch(u string) *RequestBuilder {
 	return New().Patch(u)
 }
 
 // Delete is simple DeleteRequest Builder
-func Delete(u string) *HTTPClient {
+func Delete(u string) *RequestBuilder {
 	return New().Delete(u)
 }
 
-// Head is simple HeadRequest Builder
-func Head(u string) *HTTPClient {
-	return New().Head(u)
-}
-
-func (c *HTTPClient) SetMethod(method string) *HTTPClient {
-	c.request.method = method
-	return c
-}
-
-func (c *HTTPClient) Get(u string) *HTTPClient {
-	c.request.method = http.MethodGet
-	c.request.url = u
-	return c
-}
-
-func (c *HTTPClient) Post(u string) *HTTPClient {
-	c.request.method = http.MethodPost
-	c.request.url = u
-	return c
+// DeleteWithBody is simple DeleteRequest Builder for requests with a body
+func DeleteWithBody(u string) *RequestBuilder {
+	return New().DeleteWithBody(u)
 }
 
-func (c *HTTPClient) Put(u string) *HTTPClient {
-	c.request.method = http.MethodPut
-	c.request.url = u
-	return c
-}
-
-func (c *HTTPClient) Patch(u string) *HTTPClient {
-	c.request.method = http.MethodPatch
-	c.request.url = u
-	return c
-}
-
-func (c *HTTPClient) Delete(u string) *HTTPClient {
-	c.request.method = http.MethodDelete
-	c.request.url = u
-	return c
-}
-
-func (c *HTTPClient) Head(u string) *HTTPClient {
-	c.request.method = http.MethodHead
-	c.request.url = u
-	return c
-}
-
-func (c *HTTPClient) SetURL(u string) *HTTPClient {
-	c.request.url = u
-	return c
+// Head is simple HeadRequest Builder
+func Head(u string) *RequestBuilder {
+	return New().Head(u)
 }
 
-func (c *HTTPClient) SetContentType(ct string) *HTTPClient {
-	c.request.header.Del("Content-Type")
-	c.request.header.Set("Content-Type", ct)
-	return c
+// NewRequest returns an independent RequestBuilder sharing this client's configuration
+func (c *HTTPClient) NewRequest(method, u string) *RequestBuilder {
+	return &RequestBuilder{
+		client: c,
+		method: method,
+		url:    u,
+		header: make(http.Header),
+		errs:   append([]error(nil), c.errs...),
+	}
 }
 
-func (c *HTTPClient) SetHeader(key string, value []string) *HTTPClient {
-	c.request.header[key] = value
-	return c
+func (c *HTTPClient) Get(u string) *RequestBuilder {
+	return c.NewRequest(http.MethodGet, u)
 }
 
-func (c *HTTPClient) SetHeaders(header map[string][]string) *HTTPClient {
-	c.request.header = header
-	return c
+func (c *HTTPClient) Post(u string) *RequestBuilder {
+	return c.NewRequest(http.MethodPost, u)
 }
 
-func (c *HTTPClient) AddHeader(key string, value []string) *HTTPClient {
-	for _, v := range value {
-		c.request.header.Add(key, v)
-	}
-	return c
+func (c *HTTPClient) Put(u string) *RequestBuilder {
+	return c.NewRequest(http.MethodPut, u)
 }
 
-func (c *HTTPClient) AddHeaders(header map[string][]string) *HTTPClient {
-	for k, val := range header {
-		for _, v := range val {
-			c.request.header.Add(k, v)
-		}
-	}
-	return c
+func (c *HTTPClient) Patch(u string) *RequestBuilder {
+	return c.NewRequest(http.MethodPatch, u)
 }
 
-func (c *HTTPClient) SetCookieString(cookie string) *HTTPClient {
-	c.request.header.Set("Cookie", cookie)
-	return c
+func (c *HTTPClient) Delete(u string) *RequestBuilder {
+	return c.NewRequest(http.MethodDelete, u)
 }
 
-func (c *HTTPClient) SetCookie(cookie *http.Cookie) *HTTPClient {
-	c.request.req.AddCookie(cookie)
-	return c
+// DeleteWithBody builds a DELETE request meant to carry a body, which some APIs require
+// although DELETE bodies have no defined meaning. Strict mode refuses bodies on Delete requests.
+func (c *HTTPClient) DeleteWithBody(u string) *RequestBuilder {
+	b := c.NewRequest(http.MethodDelete, u)
+	b.deleteBody = true
+	return b
 }
 
-func (c *HTTPClient) SetCookies(cookies []*http.Cookie) *HTTPClient {
-	for _, cookie := range cookies {
-		c.request.req.AddCookie(cookie)
-	}
-	return c
+func (c *HTTPClient) Head(u string) *RequestBuilder {
+	return c.NewRequest(http.MethodHead, u)
 }
 
 func (c *HTTPClient) SetCookieJar(jar *cookiejar.Jar) *HTTPClient {
@@ -238,299 +244,125 @@ func (c *HTTPClient) SetCookieJar(jar *cookiejar.Jar) *HTTPClient {
 	return c
 }
 
+// SetUserAgent sets the default User-Agent of every request
 func (c *HTTPClient) SetUserAgent(agent string) *HTTPClient {
-	c.request.header.Set("User-Agent", agent)
-	return c
-}
-
-func (c *HTTPClient) SetBody(body io.Reader) *HTTPClient {
-	c.request.body = body
-	return c
-}
-
-func (c *HTTPClient) SetBodyString(body string) *HTTPClient {
-	c.request.body = strings.NewReader(body)
-	return c
-}
-
-func (c *HTTPClient) SetBodyByte(body []byte) *HTTPClient {
-	c.request.body = bytes.NewReader(body)
-	return c
-}
-
-func (c *HTTPClient) EnableRedirct() *HTTPClient {
-	c.maxRedirect = 2
-	c.redirectEnabled = true
-	return c
-}
-
-func (c *HTTPClient) SetRequest(req *http.Request) *HTTPClient {
-	c.request.req = req
-	c.request.isRequestReady = true
+	c.userAgent = agent
 	return c
 }
 
-func (c *HTTPClient) SetBasicAuth(user, pass string) *HTTPClient {
-	c.request.basic = &BasicAuth{
-		User: user,
-		Pass: pass,
+// SetDefaultHeader sets a header sent with every request which does not set key itself
+func (c *HTTPClient) SetDefaultHeader(key, value string) *HTTPClient {
+	if c.header == nil {
+		c.header = make(http.Header)
 	}
-	return c
-}
-
-func (c *HTTPClient) SetAuth(token string) *HTTPClient {
-	c.SetHeader("Authorization", []string{token})
-	return c
-}
-
-func (c *HTTPClient) SetRedirectCount(count int) *HTTPClient {
-	c.maxRedirect = count
-	c.redirectEnabled = true
-	return c
-}
-
-func (c *HTTPClient) SetTimeout(t time.Duration) *HTTPClient {
-	c.transport.Dial = func(network, addr string) (net.Conn, error) {
-		conn, err := net.DialTimeout(network, addr, t)
-		if err != nil {
-			c.errs = append(c.errs, err)
-			return nil, err
-		}
-		conn.SetDeadline(time.Now().Add(t))
-		return conn, nil
-	}
-	c.client.Transport = c.transport
+	c.header.Set(key, value)
 	return c
 }
 
 func (c *HTTPClient) SetProxy(uri string) *HTTPClient {
 	u, err := checkURL(uri)
 	if err != nil {
-		c.errs = append(c.errs, err)
+		c.fail(ErrPhaseBuild, err)
 		return c
 	}
 	c.transport.Proxy = http.ProxyURL(u)
-	c.client.Transport = c.transport
 	return c
 }
 
 func (c *HTTPClient) SetTLSConfig(config *tls.Config) *HTTPClient {
 	c.transport.TLSClientConfig = config
-	c.client.Transport = c.transport
 	return c
 }
 
+// EnableCache caches the responses of GET and HEAD requests in a memory store of the client,
+// only cacheable statuses are stored and Cache-Control no-store and private are honoured
 func (c *HTTPClient) EnableCache() *HTTPClient {
 	c.cacheEnabled = true
-	gache.GetCache()
-	return c
-}
-
-func (c *HTTPClient) newRequest() *HTTPClient {
-	parsedURL, err := checkURL(c.request.url)
-
-	if err != nil {
-		c.errs = append(c.errs, err)
-		return c
+	if c.cache == nil {
+		c.cache = NewMemoryStore()
 	}
-
-	c.request.url = parsedURL.String()
-
-	c.request.req, err = http.NewRequest(c.request.method, c.request.url, c.request.body)
-
-	if err != nil {
-		c.errs = append(c.errs, err)
-		return c
-	}
-
-	c.request.req.Header = c.request.header
-
-	if c.request.basic != nil {
-		c.request.req.SetBasicAuth(c.request.basic.User, c.request.basic.Pass)
-	}
-
-	c.request.isRequestReady = true
-
 	return c
 }
 
-func (c *HTTPClient) Do() *HTTPClient {
-	return c.newRequest().do()
-}
-
-func (c *HTTPClient) DoWithContext(ctx context.Context) *HTTPClient {
-	c = c.newRequest()
-
-	c.request.req.WithContext(ctx)
-
-	return c.do()
-}
-
-func (c *HTTPClient) do() *HTTPClient {
-
-	if c.cacheEnabled {
-		cres, ok := gache.Get(c.request.req)
-
-		if ok {
-			c.res = cres.Res
-			c.request.isRequested = true
-			return c
-		}
-	}
-
-	var res *http.Response
-	var err error
-	res, err = c.client.Do(c.request.req)
-
-	if err != nil {
-		c.errs = append(c.errs, err)
-		return c
-	}
-
-	status := res.StatusCode
-
-	if c.redirectEnabled && c.maxRedirect > 0 && status != 300 && status/100 == 3 {
-		res, err = c.redirectRequest(c.request.req, res, 0)
-		if err != nil {
-			c.res = res
-			c.errs = append(c.errs, err)
-		}
-	}
-
-	if res.Header.Get("Content-Encoding") == "gzip" {
-		var gres io.ReadCloser
-		gres, err = gzip.NewReader(res.Body)
-		if err != nil {
-			c.res = res
-			c.errs = append(c.errs, err)
-			c.request.isRequested = true
-			return c
-		}
-		res.Body = gres
-	}
-
-	c.res = res
-
-	c.request.isRequested = true
-
-	go func() {
-		if c.cacheEnabled {
-			gache.Set(c.request.req, c.res)
-		}
-	}()
-
+// SetCacheStore replaces the cache backend and enables caching
+func (c *HTTPClient) SetCacheStore(store CacheStore) *HTTPClient {
+	c.cache = store
+	c.cacheEnabled = store != nil
 	return c
 }
 
-func (c *HTTPClient) redirectRequest(req *http.Request, res *http.Response, count int) (rres *http.Response, err error) {
-
-	if count > c.maxRedirect {
-		return res, ErrTooManyRedirection
-	}
-
-	rreq := req
-
-	loc := res.Header.Get("Location")
-
-	if len(loc) == 0 {
-		return res, ErrInvalidRedirectLocation
-	}
-
-	rreq.URL, err = url.ParseRequestURI(loc)
-	if err == nil {
-		rres, err = c.client.Transport.RoundTrip(rreq)
-		if err == nil {
-			switch rres.StatusCode / 100 {
-			case 2:
-				return rres, nil
-			case 3:
-				return c.redirectRequest(rreq, rres, count+1)
-			case 4, 5:
-				return rres, errors.New(http.StatusText(rres.StatusCode))
-			}
-		}
-	}
-	return res, err
+// GetErrors returns the errors raised while configuring the client
+func (c *HTTPClient) GetErrors() []error {
+	return c.errs
 }
 
-func (c *HTTPClient) JSON(d interface{}) *HTTPClient {
-	if !c.request.isRequested {
-		c.Do()
+func (c *HTTPClient) ResetCache() *HTTPClient {
+	if c.cache == nil {
+		return c
 	}
-	err := json.NewDecoder(c.res.Body).Decode(d)
+	c.expiry.reset()
+	err := c.cache.Clear()
 	if err != nil {
-		c.errs = append(c.errs, err)
+		c.fail(ErrPhaseBuild, err)
 	}
 	return c
 }
 
-func (c *HTTPClient) XML(d interface{}) *HTTPClient {
-	if !c.request.isRequested {
-		c.Do()
-	}
-	err := xml.NewDecoder(c.res.Body).Decode(d)
-	if err != nil {
-		c.errs = append(c.errs, err)
-	}
-	return c
+// ResetClient returns a new client, c and its pooled connections are left as they are.
+//
+// Deprecated: use Reset to clear the state of c, or HardReset to rebuild it.
+func (c *HTTPClient) ResetClient() *HTTPClient {
+	return New()
 }
 
-func (c *HTTPClient) GetByteBody() ([]byte, []error) {
-	if !c.request.isRequested {
-		c.Do()
+// Reset clears the state c accumulated from earlier requests while keeping its configuration,
+// transport with the pooled connections, cookie jar and middlewares: configuration errors,
+// circuit breakers, host pool ejections, cached DNS answers and header provider values, and
+// the shapes recorded by EnableSchemaDrift. Stats keep counting, the cache is kept, see ResetCache.
+func (c *HTTPClient) Reset() *HTTPClient {
+	c.errs = nil
+	if c.breakers != nil {
+		c.breakers.mu.Lock()
+		c.breakers.hosts = make(map[string]*circuit)
+		c.breakers.mu.Unlock()
+	}
+	if c.hostPool != nil {
+		c.hostPool.mu.Lock()
+		for _, h := range c.hostPool.hosts {
+			h.failures = 0
+			h.downUntil = time.Time{}
+		}
+		c.hostPool.mu.Unlock()
 	}
-	var body io.ReadWriter
-	io.Copy(body, c.res.Body)
-	b, err := ioutil.ReadAll(body)
-	if err != nil {
-		c.errs = append(c.errs, err)
+	if c.dns != nil {
+		c.dns.mu.Lock()
+		c.dns.entries = make(map[string]*dnsEntry)
+		c.dns.mu.Unlock()
 	}
-	return b, c.errs
-}
-
-func (c *HTTPClient) GetRawBody() (io.ReadCloser, []error) {
-	if !c.request.isRequested {
-		c.Do()
+	for _, p := range c.headerProviders {
+		p.mu.Lock()
+		p.value = ""
+		p.expires = time.Time{}
+		p.mu.Unlock()
 	}
-	return c.res.Body, c.errs
-}
-
-func (c *HTTPClient) GetRequest() (*http.Request, []error) {
-	if c.request.isRequestReady {
-		return c.request.req, c.errs
-	}
-	return c.newRequest().request.req, c.errs
-}
-
-func (c *HTTPClient) GetResponse() (*http.Response, []error) {
-	if !c.request.isRequested {
-		c.Do()
+	if c.drift != nil {
+		c.drift.mu.Lock()
+		c.drift.shapes = make(map[string]map[string]string)
+		c.drift.mu.Unlock()
 	}
-	return c.res, c.errs
-}
-
-func (c *HTTPClient) GetErrors() []error {
-	return c.errs
-}
-
-func (c *HTTPClient) ResetCache() *HTTPClient {
-	gache.GetCache().Clear()
 	return c
 }
 
-func (c *HTTPClient) ResetClient() *HTTPClient {
-	return New()
-}
-
-func (c *HTTPClient) Close() []error {
-	io.Copy(ioutil.Discard, c.res.Body)
-	err := c.res.Body.Close()
-	if err != nil {
-		c.errs = append(c.errs, err)
-	}
-	errs := c.errs
-	c = nil
-	return errs
+// HardReset closes the idle connections of c and rebuilds it as returned by New, dropping its
+// configuration, cookies, middlewares, archiving and pending cache expiries. Everything holding c sees the
+// new state, so it must not be called while requests are in flight.
+func (c *HTTPClient) HardReset() *HTTPClient {
+	c.CloseIdleConnections()
+	c.StopArchiving()
+	c.expiry.reset()
+	*c = HTTPClient{}
+	c.init()
+	return c
 }
 
 func checkURL(u string) (*url.URL, error) {
@@ -0,0 +1,56 @@
+// Package httgotest provides test helpers for code built on httgo.
+package httgotest
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kpango/httgo"
+)
+
+// settleTimeout is how long VerifyClean waits for bodies closed by other goroutines
+const settleTimeout = time.Second
+
+// VerifyClean fails t when response bodies of c were neither closed nor read to the end, or when connections
+// stay open once the idle ones are closed, which means a body still holds them.
+// Call it at the end of a test, e.g. with t.Cleanup. It closes c's idle connections.
+func VerifyClean(t testing.TB, c *httgo.HTTPClient) {
+	t.Helper()
+
+	deadline := time.Now().Add(settleTimeout)
+	st := c.Stats()
+	for st.OpenBodies > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		st = c.Stats()
+	}
+	if st.OpenBodies > 0 {
+		t.Errorf("httgo: %d response bodies were not closed", st.OpenBodies)
+	}
+
+	c.ResetTransport()
+	leaked := connections(c)
+	for len(leaked) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		leaked = connections(c)
+	}
+	hosts := make([]string, 0, len(leaked))
+	for host := range leaked {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		t.Errorf("httgo: %d connections to %s are still in use", leaked[host], host)
+	}
+}
+
+// connections returns the open connections per dialed address
+func connections(c *httgo.HTTPClient) map[string]int64 {
+	conns := make(map[string]int64)
+	for host, hs := range c.Stats().Hosts {
+		if hs.Connections > 0 {
+			conns[host] = hs.Connections
+		}
+	}
+	return conns
+}
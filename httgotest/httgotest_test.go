@@ -0,0 +1,55 @@
+package httgotest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kpango/httgo"
+)
+
+// recorder collects the failures VerifyClean reports
+type recorder struct {
+	testing.TB
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestVerifyClean(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer srv.Close()
+
+	t.Run("closed bodies", func(t *testing.T) {
+		c := httgo.New()
+		if _, errs := c.Get(srv.URL).String(); len(errs) != 0 {
+			t.Fatal(errs)
+		}
+		c.Get(srv.URL).Do().Close()
+
+		r := &recorder{TB: t}
+		VerifyClean(r, c)
+		if len(r.errs) != 0 {
+			t.Fatalf("unexpected failures: %q", r.errs)
+		}
+	})
+
+	t.Run("unclosed body", func(t *testing.T) {
+		c := httgo.New()
+		b := c.Get(srv.URL).Do()
+		defer b.Close()
+
+		r := &recorder{TB: t}
+		VerifyClean(r, c)
+		if len(r.errs) != 2 {
+			t.Fatalf("failures = %q, want the open body and its connection", r.errs)
+		}
+	})
+}
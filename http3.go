@@ -0,0 +1,20 @@
+//go:build http3
+// +build http3
+
+package httgo
+
+import (
+	"github.com/quic-go/quic-go/http3"
+)
+
+// EnableHTTP3 sends every request over HTTP/3 (QUIC) using the current TLS configuration.
+// It is experimental: servers without HTTP/3 support are not retried over TCP,
+// and dialer, proxy and unix socket settings do not apply. Requests fail with ErrSSRFOverHTTP3
+// when EnableSSRFProtection is set as well.
+// It is only available when building with the http3 tag.
+func (c *HTTPClient) EnableHTTP3() *HTTPClient {
+	c.http3 = &http3.Transport{
+		TLSClientConfig: c.transport.TLSClientConfig.Clone(),
+	}
+	return c
+}
@@ -0,0 +1,78 @@
+package httgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// HTTPError is appended to the request errors for 4xx/5xx responses
+// when FailOnHTTPError or SetError is used.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+// ErrorDecoder converts an error response into an error
+type ErrorDecoder func(res *http.Response, body []byte) error
+
+func (e *HTTPError) Error() string {
+	return "HTTP Error " + e.Status
+}
+
+// FailOnHTTPError turns 4xx/5xx responses into *HTTPError
+func (c *HTTPClient) FailOnHTTPError() *HTTPClient {
+	c.failOnHTTPError = true
+	return c
+}
+
+// SetErrorDecoder sets a custom conversion of 4xx/5xx responses into errors
+func (c *HTTPClient) SetErrorDecoder(dec ErrorDecoder) *HTTPClient {
+	c.errorDecoder = dec
+	c.failOnHTTPError = dec != nil
+	return c
+}
+
+// SetError decodes the JSON body of a 4xx/5xx response into d
+func (b *RequestBuilder) SetError(d interface{}) *RequestBuilder {
+	b.errResult = d
+	return b
+}
+
+func (b *RequestBuilder) checkHTTPError(res *http.Response) {
+	if res.StatusCode < 400 || (!b.client.failOnHTTPError && b.errResult == nil) {
+		return
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+		return
+	}
+
+	if b.errResult != nil && len(body) != 0 {
+		err = b.client.decodeJSON(bytes.NewReader(body), b.errResult)
+		if err != nil {
+			b.fail(ErrPhaseDecode, err)
+		}
+	}
+
+	if b.client.errorDecoder != nil {
+		err = b.client.errorDecoder(res, body)
+		if err != nil {
+			b.fail(ErrPhaseResponse, err)
+		}
+		return
+	}
+
+	b.fail(ErrPhaseResponse, &HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+		Body:       body,
+	})
+}
@@ -0,0 +1,52 @@
+package httgo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetAcceptLanguage sets Accept-Language from tags ordered by preference,
+// generating decreasing quality values, e.g. "ja-JP, ja;q=0.9, en;q=0.8"
+func (b *RequestBuilder) SetAcceptLanguage(tags ...string) *RequestBuilder {
+	b.unsent("SetAcceptLanguage")
+	if len(tags) == 0 {
+		b.header.Del("Accept-Language")
+		return b
+	}
+
+	step := 100
+	if len(tags) > 10 {
+		step = 1000 / len(tags)
+	}
+
+	list := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		if i == 0 {
+			list = append(list, tag)
+			continue
+		}
+		q := 1000 - i*step
+		list = append(list, tag+";q="+strconv.FormatFloat(float64(q)/1000, 'f', -1, 64))
+	}
+
+	b.header.Set("Accept-Language", strings.Join(list, ", "))
+	return b
+}
+
+// ContentLanguage returns the language tags of the response Content-Language header
+func (b *RequestBuilder) ContentLanguage() []string {
+	h := b.Header()
+	if h == nil {
+		return nil
+	}
+	var tags []string
+	for _, v := range h["Content-Language"] {
+		for _, tag := range strings.Split(v, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,182 @@
+package httgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsMetadataURL   = "http://169.254.169.254"
+	gcpMetadataURL   = "http://metadata.google.internal/computeMetadata/v1/"
+	azureMetadataURL = "http://169.254.169.254/metadata/"
+
+	defaultAzureMetadataAPIVersion = "2021-02-01"
+
+	awsMetadataTokenTTL = 6 * time.Hour
+	metadataTimeout     = 2 * time.Second
+	metadataDialTimeout = time.Second
+	metadataMaxBodySize = 1 << 20
+)
+
+// ErrMetadataUnavailable is returned when the metadata endpoint does not identify as the expected provider
+var ErrMetadataUnavailable = errors.New("Metadata Service Unavailable")
+
+// MetadataClient queries the AWS, GCP and Azure instance metadata services.
+// It never uses the proxy nor the SSRF protection of the client it was created from,
+// so reuse it to share the IMDSv2 session token between calls.
+type MetadataClient struct {
+	client      *http.Client
+	userAgent   string
+	mu          sync.Mutex
+	awsToken    string
+	awsTokenExp time.Time
+}
+
+// Metadata returns a MetadataClient with tight timeouts
+func (c *HTTPClient) Metadata() *MetadataClient {
+	dialer := &net.Dialer{
+		Timeout: metadataDialTimeout,
+	}
+	return &MetadataClient{
+		client: &http.Client{
+			Timeout: metadataTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				MaxIdleConnsPerHost: 2,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		userAgent: c.userAgent,
+	}
+}
+
+// AWS returns the IMDSv2 value at path, e.g. "meta-data/instance-id",
+// fetching a session token first
+func (m *MetadataClient) AWS(ctx context.Context, path string) ([]byte, error) {
+	tok, err := m.awsSessionToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := m.newRequest(ctx, http.MethodGet, awsMetadataURL+"/latest/"+strings.TrimPrefix(strings.TrimPrefix(path, "/"), "latest/"))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", tok)
+
+	body, _, err := m.do(req)
+	if e, ok := err.(*HTTPError); ok && e.StatusCode == http.StatusUnauthorized {
+		m.mu.Lock()
+		m.awsToken = ""
+		m.mu.Unlock()
+	}
+	return body, err
+}
+
+// GCP returns the metadata value at path, e.g. "instance/id", relative to computeMetadata/v1
+func (m *MetadataClient) GCP(ctx context.Context, path string) ([]byte, error) {
+	req, err := m.newRequest(ctx, http.MethodGet, gcpMetadataURL+strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	body, header, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if header.Get("Metadata-Flavor") != "Google" {
+		return nil, ErrMetadataUnavailable
+	}
+	return body, nil
+}
+
+// Azure returns the IMDS value at path, e.g. "instance/compute/vmId?format=text",
+// adding a default api-version when the path has none
+func (m *MetadataClient) Azure(ctx context.Context, path string) ([]byte, error) {
+	u := azureMetadataURL + strings.TrimPrefix(strings.TrimPrefix(path, "/"), "metadata/")
+	if !strings.Contains(u, "api-version=") {
+		if strings.Contains(u, "?") {
+			u += "&api-version=" + defaultAzureMetadataAPIVersion
+		} else {
+			u += "?api-version=" + defaultAzureMetadataAPIVersion
+		}
+	}
+
+	req, err := m.newRequest(ctx, http.MethodGet, u)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	body, _, err := m.do(req)
+	return body, err
+}
+
+func (m *MetadataClient) awsSessionToken(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.awsToken != "" && time.Now().Before(m.awsTokenExp) {
+		return m.awsToken, nil
+	}
+
+	req, err := m.newRequest(ctx, http.MethodPut, awsMetadataURL+"/latest/api/token")
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", strconv.Itoa(int(awsMetadataTokenTTL/time.Second)))
+
+	body, _, err := m.do(req)
+	if err != nil {
+		return "", err
+	}
+
+	m.awsToken = string(body)
+	// refresh well before the service expires it
+	m.awsTokenExp = time.Now().Add(awsMetadataTokenTTL - time.Minute)
+	return m.awsToken, nil
+}
+
+func (m *MetadataClient) newRequest(ctx context.Context, method, u string) (*http.Request, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if m.userAgent != "" {
+		req.Header.Set("User-Agent", m.userAgent)
+	}
+	return req.WithContext(ctx), nil
+}
+
+func (m *MetadataClient) do(req *http.Request) ([]byte, http.Header, error) {
+	res, err := m.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, metadataMaxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		return nil, nil, &HTTPError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Header:     res.Header,
+			Body:       body,
+		}
+	}
+	return body, res.Header, nil
+}
@@ -0,0 +1,75 @@
+package httgo
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// MetricsLabels identifies the request a metric belongs to
+type MetricsLabels struct {
+	Method string
+	Host   string
+	// StatusCode is 0 for requests which failed without a response
+	StatusCode int
+	// Variant is VariantPrimary or VariantCanary for requests split by SplitTraffic, empty otherwise
+	Variant string
+	// Route is the URL template of SetURLTemplateFunc, empty without it
+	Route string
+}
+
+// MetricsCollector receives client metrics, e.g. to feed Prometheus collectors
+type MetricsCollector interface {
+	// IncInFlight and DecInFlight bracket each request sent to the network
+	IncInFlight(l MetricsLabels)
+	DecInFlight(l MetricsLabels)
+	// ObserveRequest is called once per request with the time until response headers
+	ObserveRequest(l MetricsLabels, d time.Duration)
+	// ObserveResponseSize is called with the body size once it is read or closed
+	ObserveResponseSize(l MetricsLabels, size int64)
+	CacheHit(l MetricsLabels)
+	CacheMiss(l MetricsLabels)
+}
+
+type metricsBody struct {
+	io.ReadCloser
+	collector MetricsCollector
+	labels    MetricsLabels
+	n         int64
+	observed  bool
+}
+
+// SetMetricsCollector reports request metrics to m
+func (c *HTTPClient) SetMetricsCollector(m MetricsCollector) *HTTPClient {
+	c.metrics = m
+	return c
+}
+
+func requestLabels(req *http.Request) MetricsLabels {
+	return MetricsLabels{
+		Method: req.Method,
+		Host:   req.URL.Host,
+	}
+}
+
+func (m *metricsBody) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	m.n += int64(n)
+	if err == io.EOF {
+		m.observe()
+	}
+	return n, err
+}
+
+func (m *metricsBody) Close() error {
+	m.observe()
+	return m.ReadCloser.Close()
+}
+
+func (m *metricsBody) observe() {
+	if m.observed {
+		return
+	}
+	m.observed = true
+	m.collector.ObserveResponseSize(m.labels, m.n)
+}
@@ -0,0 +1,181 @@
+package httgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MockTransport answers requests with canned responses instead of the network,
+// so code built on the client can be tested without httptest servers
+type MockTransport struct {
+	mu     sync.Mutex
+	routes []*MockRoute
+}
+
+// MockResponder builds the response of a matched request
+type MockResponder func(req *http.Request) (*http.Response, error)
+
+// MockRoute is a matcher registered on a MockTransport and its response
+type MockRoute struct {
+	m       *MockTransport
+	match   func(req *http.Request) bool
+	respond MockResponder
+	status  int
+	header  http.Header
+	body    []byte
+	times   int
+	calls   int
+}
+
+// ErrNoMockMatch is returned for requests no MockRoute matches
+var ErrNoMockMatch = errors.New("No Mock Response Matched")
+
+// NewMockTransport returns an empty MockTransport
+func NewMockTransport() *MockTransport {
+	return new(MockTransport)
+}
+
+// SetMockTransport serves every request from m. Middlewares, signing, redirects,
+// caching and decoding still run, only the network is replaced.
+func (c *HTTPClient) SetMockTransport(m *MockTransport) *HTTPClient {
+	c.stub = m
+	return c
+}
+
+// On registers a route matching method ("" for any) and u. A u ending in "*" matches
+// every URL with that prefix, otherwise the URL must be equal, ignoring query parameter order.
+func (m *MockTransport) On(method, u string) *MockRoute {
+	return m.OnMatch(func(req *http.Request) bool {
+		if method != "" && !strings.EqualFold(method, req.Method) {
+			return false
+		}
+		if strings.HasSuffix(u, "*") {
+			return strings.HasPrefix(req.URL.String(), strings.TrimSuffix(u, "*"))
+		}
+		return sameURL(req, u)
+	})
+}
+
+// OnMatch registers a route matching the requests fn reports true for
+func (m *MockTransport) OnMatch(fn func(req *http.Request) bool) *MockRoute {
+	r := &MockRoute{
+		m:      m,
+		match:  fn,
+		status: http.StatusOK,
+		header: make(http.Header),
+	}
+	m.mu.Lock()
+	m.routes = append(m.routes, r)
+	m.mu.Unlock()
+	return r
+}
+
+// Reset removes every route
+func (m *MockTransport) Reset() {
+	m.mu.Lock()
+	m.routes = nil
+	m.mu.Unlock()
+}
+
+// RoundTrip answers req with the first matching route which is not exhausted
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	var route *MockRoute
+	for _, r := range m.routes {
+		if (r.times <= 0 || r.calls < r.times) && r.match(req) {
+			r.calls++
+			route = r
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if route == nil {
+		return nil, ErrNoMockMatch
+	}
+	if route.respond != nil {
+		return route.respond(req)
+	}
+	return newResponse(req, route.status, route.header.Clone(), route.body), nil
+}
+
+// Reply answers with status and body
+func (r *MockRoute) Reply(status int, body string) *MockRoute {
+	r.status = status
+	r.body = []byte(body)
+	return r
+}
+
+// ReplyJSON answers with status and v encoded as JSON
+func (r *MockRoute) ReplyJSON(status int, v interface{}) *MockRoute {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return r.Respond(func(*http.Request) (*http.Response, error) {
+			return nil, err
+		})
+	}
+	r.status = status
+	r.body = body
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// SetHeader sets a response header
+func (r *MockRoute) SetHeader(key, value string) *MockRoute {
+	r.header.Set(key, value)
+	return r
+}
+
+// Respond builds responses with fn, e.g. to return transport errors
+func (r *MockRoute) Respond(fn MockResponder) *MockRoute {
+	r.respond = fn
+	return r
+}
+
+// Times limits the route to n matches, zero means unlimited
+func (r *MockRoute) Times(n int) *MockRoute {
+	r.times = n
+	return r
+}
+
+// Calls returns how many requests the route answered
+func (r *MockRoute) Calls() int {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.calls
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// sameURL compares the URL of req with u, ignoring the order of query parameters
+func sameURL(req *http.Request, u string) bool {
+	r2, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return false
+	}
+	a, b := *req.URL, *r2.URL
+	aq, bq := a.Query(), b.Query()
+	a.RawQuery, b.RawQuery = "", ""
+	return a.String() == b.String() && aq.Encode() == bq.Encode()
+}
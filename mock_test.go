@@ -0,0 +1,27 @@
+package httgo
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestMockRouteCallsConcurrent(t *testing.T) {
+	m := NewMockTransport()
+	route := m.On(http.MethodGet, "http://example.com/*").Reply(http.StatusOK, "ok")
+	c := New().SetMockTransport(m)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Get("http://example.com/a").Do().Close()
+			route.Calls()
+		}()
+	}
+	wg.Wait()
+	if n := route.Calls(); n != 8 {
+		t.Fatalf("calls = %d, want 8", n)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build msgpack
+// +build msgpack
+
+package httgo
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack decoding is only available when building with the msgpack tag
+func init() {
+	decode := func(r io.Reader, v interface{}) error {
+		return msgpack.NewDecoder(r).Decode(v)
+	}
+	for _, ct := range []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"} {
+		decoders[ct] = decode
+	}
+}
@@ -0,0 +1,103 @@
+package httgo
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DecoderFunc decodes a response body into v
+type DecoderFunc func(r io.Reader, v interface{}) error
+
+var (
+	// ErrUnsupportedContentType is returned by Decode for a Content-Type without a decoder
+	ErrUnsupportedContentType = errors.New("Unsupported Content-Type")
+	// ErrUnsupportedDecodeTarget is returned for form bodies decoded into anything
+	// but *url.Values, *map[string][]string or *map[string]string
+	ErrUnsupportedDecodeTarget = errors.New("Unsupported Decode Target")
+
+	decodersMu sync.RWMutex
+	// decoders holds the registered media types, YAML and MessagePack are registered by
+	// yaml.go and msgpack.go when building with the yaml and msgpack tags
+	decoders = map[string]DecoderFunc{
+		"application/x-www-form-urlencoded": decodeForm,
+	}
+)
+
+// RegisterDecoder makes Decode use fn for responses of contentType (a media type such as
+// "text/csv"), replacing the built-in decoder of that type. It is safe for concurrent use.
+func RegisterDecoder(contentType string, fn DecoderFunc) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(contentType)] = fn
+}
+
+// Decode decodes the response body into v according to its Content-Type:
+// JSON (including +json types), XML (including +xml types), form-urlencoded and
+// any type passed to RegisterDecoder
+func (b *RequestBuilder) Decode(v interface{}) *RequestBuilder {
+	res := b.response()
+	if res == nil {
+		return b
+	}
+	if !b.consume("Decode", false) {
+		return b
+	}
+	defer res.Body.Close()
+
+	mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		b.fail(ErrPhaseDecode, ErrUnsupportedContentType)
+		return b
+	}
+
+	decodersMu.RLock()
+	fn, ok := decoders[mt]
+	decodersMu.RUnlock()
+	switch {
+	case ok:
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		fn = b.client.decodeJSON
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		fn = b.client.decodeXML
+	default:
+		b.fail(ErrPhaseDecode, ErrUnsupportedContentType)
+		return b
+	}
+
+	err = fn(res.Body, v)
+	if err != nil {
+		b.fail(ErrPhaseDecode, err)
+	}
+	return b
+}
+
+func decodeForm(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	vals, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch d := v.(type) {
+	case *url.Values:
+		*d = vals
+	case *map[string][]string:
+		*d = vals
+	case *map[string]string:
+		m := make(map[string]string, len(vals))
+		for k := range vals {
+			m[k] = vals.Get(k)
+		}
+		*d = m
+	default:
+		return ErrUnsupportedDecodeTarget
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package httgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// bodilessMethods are the methods whose requests usually carry no body
+var bodilessMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// NoBody sends the request without a body, dropping any body and Content-Type set on it.
+// POST, PUT and PATCH requests send Content-Length: 0, other methods neither Content-Length
+// nor Transfer-Encoding.
+func (b *RequestBuilder) NoBody() *RequestBuilder {
+	b.unsent("NoBody")
+	b.noBody = true
+	return b
+}
+
+// emptyBody drops the body of NoBody requests and empty bodies of bodiless methods.
+// Their other bodies are buffered, so they are sent with a Content-Length instead of chunked.
+func (b *RequestBuilder) emptyBody() error {
+	if b.noBody {
+		b.body = nil
+		b.bodyStream = false
+		b.header.Del("Content-Type")
+		return nil
+	}
+	if b.body == nil || b.bodyStream || !bodilessMethods[b.method] {
+		return nil
+	}
+
+	switch r := b.body.(type) {
+	case *bytes.Reader:
+		if r.Len() == 0 {
+			b.body = nil
+		}
+		return nil
+	case *strings.Reader:
+		if r.Len() == 0 {
+			b.body = nil
+		}
+		return nil
+	case *bytes.Buffer:
+		if r.Len() == 0 {
+			b.body = nil
+		}
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(b.body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		b.body = nil
+		return nil
+	}
+	b.body = bytes.NewReader(data)
+	return nil
+}
@@ -0,0 +1,30 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoBody(t *testing.T) {
+	var length int64
+	var te []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		length, te = r.ContentLength, r.TransferEncoding
+	}))
+	defer srv.Close()
+
+	if errs := New().Post(srv.URL).SetBodyString("dropped").NoBody().Do().Close(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if length != 0 || len(te) != 0 {
+		t.Fatalf("content length %d, transfer encoding %v, want an empty body", length, te)
+	}
+
+	b := New().EnableStrictMode().Post(srv.URL).Do()
+	b.NoBody()
+	if errs := b.Close(); len(errs) == 0 || !errors.Is(errs[len(errs)-1], ErrMisuse) {
+		t.Fatalf("NoBody after Do: want ErrMisuse, got %v", errs)
+	}
+}
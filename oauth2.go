@@ -0,0 +1,21 @@
+//go:build oauth2
+// +build oauth2
+
+package httgo
+
+import (
+	"golang.org/x/oauth2"
+)
+
+// SetOAuth2TokenSource sends tokens from ts, refreshing them before they expire.
+// It is only available when building with the oauth2 tag.
+func (c *HTTPClient) SetOAuth2TokenSource(ts oauth2.TokenSource) *HTTPClient {
+	ts = oauth2.ReuseTokenSource(nil, ts)
+	return c.SetTokenSource(func() (string, string, error) {
+		tok, err := ts.Token()
+		if err != nil {
+			return "", "", err
+		}
+		return tok.Type(), tok.AccessToken, nil
+	})
+}
@@ -0,0 +1,267 @@
+// Package oci pulls manifests and blobs from OCI / Docker registries over an httgo client.
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/kpango/httgo"
+)
+
+// Manifest media types accepted by Manifest, in order of preference
+const (
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// Client talks to a single registry
+type Client struct {
+	client   *httgo.HTTPClient
+	base     string
+	username string
+	password string
+	mu       sync.Mutex
+	tokens   map[string]string
+}
+
+// Manifest is a manifest or index as returned by the registry
+type Manifest struct {
+	MediaType string
+	Digest    string
+	Body      []byte
+}
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+var (
+	// ErrDigestMismatch is returned when downloaded content does not match its digest
+	ErrDigestMismatch = errors.New("Digest Mismatch")
+	// ErrUnsupportedDigest is returned for digests using an unknown algorithm
+	ErrUnsupportedDigest = errors.New("Unsupported Digest Algorithm")
+	// ErrUnsupportedAuth is returned for registry challenges other than Bearer and Basic
+	ErrUnsupportedAuth = errors.New("Unsupported Registry Authentication")
+)
+
+// New returns a Client for registry, e.g. "registry-1.docker.io" or "http://localhost:5000"
+func New(c *httgo.HTTPClient, registry string) *Client {
+	if !strings.Contains(registry, "://") {
+		registry = "https://" + registry
+	}
+	return &Client{
+		client: c,
+		base:   strings.TrimSuffix(registry, "/"),
+		tokens: make(map[string]string),
+	}
+}
+
+// SetCredentials sets the credentials used for token and basic authentication
+func (c *Client) SetCredentials(user, pass string) *Client {
+	c.username = user
+	c.password = pass
+	return c
+}
+
+// Manifest fetches the manifest of repo at reference (a tag or a digest) and verifies its digest
+func (c *Client) Manifest(ctx context.Context, repo, reference string) (*Manifest, error) {
+	b, err := c.do(ctx, "/v2/"+repo+"/manifests/"+reference, repo,
+		MediaTypeOCIManifest,
+		MediaTypeOCIIndex,
+		MediaTypeDockerManifest,
+		MediaTypeDockerManifestList,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	body, errs := b.GetByteBody()
+	b.Close()
+	if len(errs) != 0 {
+		return nil, errs[len(errs)-1]
+	}
+
+	m := &Manifest{
+		MediaType: b.Header().Get("Content-Type"),
+		Digest:    b.Header().Get("Docker-Content-Digest"),
+		Body:      body,
+	}
+
+	if strings.Contains(reference, ":") {
+		m.Digest = reference
+	}
+	if m.Digest != "" {
+		h, err := newHash(m.Digest)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(body)
+		if !matchDigest(h, m.Digest) {
+			return nil, ErrDigestMismatch
+		}
+	}
+
+	return m, nil
+}
+
+// FetchBlob streams the blob of repo identified by digest into w, verifying the digest.
+// On mismatch w has already received the bad content and ErrDigestMismatch is returned.
+func (c *Client) FetchBlob(ctx context.Context, repo, digest string, w io.Writer) error {
+	h, err := newHash(digest)
+	if err != nil {
+		return err
+	}
+
+	b, err := c.do(ctx, "/v2/"+repo+"/blobs/"+digest, repo)
+	if err != nil {
+		return err
+	}
+
+	_, errs := b.Download(io.MultiWriter(w, h))
+	if len(errs) != 0 {
+		return errs[len(errs)-1]
+	}
+	if !matchDigest(h, digest) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, path, repo string, accept ...string) (*httgo.RequestBuilder, error) {
+	scope := "repository:" + repo + ":pull"
+
+	for attempt := 0; ; attempt++ {
+		b := c.client.Get(c.base + path)
+		for _, a := range accept {
+			b.AddHeader("Accept", []string{a})
+		}
+		c.authorize(b, scope)
+		b.DoWithContext(ctx)
+
+		status := b.StatusCode()
+		if status == 401 && attempt == 0 {
+			chal := b.Header().Get("WWW-Authenticate")
+			b.Close()
+			err := c.authenticate(ctx, chal, scope)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if errs := b.GetErrors(); len(errs) != 0 {
+			b.Close()
+			return nil, errs[len(errs)-1]
+		}
+		if status/100 != 2 {
+			body, _ := b.GetByteBody()
+			b.Close()
+			return nil, &httgo.HTTPError{
+				StatusCode: status,
+				Status:     b.Status(),
+				Header:     b.Header(),
+				Body:       body,
+			}
+		}
+		return b, nil
+	}
+}
+
+func (c *Client) authorize(b *httgo.RequestBuilder, scope string) {
+	c.mu.Lock()
+	tok, ok := c.tokens[scope]
+	c.mu.Unlock()
+	switch {
+	case ok && tok != "":
+		b.SetBearerToken(tok)
+	case ok && c.username != "":
+		b.SetBasicAuth(c.username, c.password)
+	}
+}
+
+func (c *Client) authenticate(ctx context.Context, chal, scope string) error {
+	scheme, params := httgo.ParseAuthChallenge(chal)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		c.mu.Lock()
+		c.tokens[scope] = ""
+		c.mu.Unlock()
+		return nil
+	case "bearer":
+	default:
+		return ErrUnsupportedAuth
+	}
+
+	q := url.Values{}
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	// the token is requested for the scope of the challenge, which may differ in
+	// its actions, but stored under the scope the caller looks it up with
+	requested := scope
+	if s := params["scope"]; s != "" {
+		requested = s
+	}
+	q.Set("scope", requested)
+
+	realm := params["realm"]
+	if strings.Contains(realm, "?") {
+		realm += "&" + q.Encode()
+	} else {
+		realm += "?" + q.Encode()
+	}
+
+	b := c.client.Get(realm)
+	if c.username != "" {
+		b.SetBasicAuth(c.username, c.password)
+	}
+
+	var tr tokenResponse
+	errs := b.DoWithContext(ctx).JSON(&tr).Close()
+	if len(errs) != 0 {
+		return errs[len(errs)-1]
+	}
+	if !b.IsSuccess() {
+		return &httgo.HTTPError{
+			StatusCode: b.StatusCode(),
+			Status:     b.Status(),
+			Header:     b.Header(),
+		}
+	}
+
+	tok := tr.Token
+	if tok == "" {
+		tok = tr.AccessToken
+	}
+
+	c.mu.Lock()
+	c.tokens[scope] = tok
+	c.mu.Unlock()
+	return nil
+}
+
+func newHash(digest string) (hash.Hash, error) {
+	switch {
+	case strings.HasPrefix(digest, "sha256:"):
+		return sha256.New(), nil
+	case strings.HasPrefix(digest, "sha512:"):
+		return sha512.New(), nil
+	}
+	return nil, ErrUnsupportedDigest
+}
+
+func matchDigest(h hash.Hash, digest string) bool {
+	return hex.EncodeToString(h.Sum(nil)) == digest[strings.IndexByte(digest, ':')+1:]
+}
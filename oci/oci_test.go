@@ -0,0 +1,53 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kpango/httgo"
+)
+
+func TestTokenReusedForChallengeScope(t *testing.T) {
+	blob := []byte("layer")
+	sum := sha256.Sum256(blob)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var tokens int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			atomic.AddInt32(&tokens, 1)
+			if scope := r.URL.Query().Get("scope"); scope != "repository:lib/a:pull,push" {
+				t.Errorf("token requested for scope %q", scope)
+			}
+			w.Write([]byte(`{"token":"tok"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			// the registry asks for more actions than the pull scope of the client
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="reg",scope="repository:lib/a:pull,push"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write(blob)
+	}))
+	defer srv.Close()
+
+	c := New(httgo.New(), srv.URL)
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		err := c.FetchBlob(context.Background(), "lib/a", digest, &buf)
+		if err != nil || buf.String() != "layer" {
+			t.Fatalf("fetch %d: %v %q", i, err, buf.String())
+		}
+	}
+	if n := atomic.LoadInt32(&tokens); n != 1 {
+		t.Fatalf("token requests = %d, want 1", n)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build otel
+// +build otel
+
+package httgo
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const otelInstrumentationName = "github.com/kpango/httgo"
+
+// EnableOTel starts a client span for every attempt, including redirect hops and retries,
+// and injects the trace context into the outgoing headers with prop.
+// It is only available when building with the otel tag.
+func (c *HTTPClient) EnableOTel(tp trace.TracerProvider, prop propagation.TextMapPropagator) *HTTPClient {
+	tracer := tp.Tracer(otelInstrumentationName)
+	return c.Use(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			name := "HTTP " + req.Method
+			route := c.routeOf(req)
+			if route != "" {
+				name += " " + route
+			}
+			ctx, span := tracer.Start(req.Context(), name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("url.full", req.URL.String()),
+					attribute.String("server.address", req.URL.Hostname()),
+				),
+			)
+			defer span.End()
+
+			if route != "" {
+				span.SetAttributes(attribute.String("url.template", route))
+			}
+			if a, ok := AttemptFromContext(req.Context()); ok && a.Number > 1 {
+				span.SetAttributes(attribute.Int("http.request.resend_count", a.Number-1))
+			}
+
+			if st := cacheStatusFromContext(req.Context()); st != "" {
+				span.SetAttributes(attribute.String("httgo.cache.status", st))
+			}
+			if from := rewrittenFromContext(req.Context()); from != "" {
+				span.SetAttributes(attribute.String("httgo.url.original", from))
+			}
+
+			req = req.Clone(ctx)
+			prop.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			res, err := next.RoundTrip(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return res, err
+			}
+
+			span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+			if res.StatusCode >= 400 {
+				span.SetStatus(codes.Error, res.Status)
+			}
+			return res, nil
+		})
+	})
+}
@@ -0,0 +1,214 @@
+package httgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PaginateOptions configures Paginate
+type PaginateOptions struct {
+	// NewPage returns the value each page's JSON body is decoded into,
+	// the raw body is passed as []byte when it is nil
+	NewPage func() interface{}
+	// OnPage is called with every decoded page in order, returning ErrStopPagination ends early
+	OnPage func(page interface{}, res *http.Response) error
+	// Next extracts where the next page is from a page, "" ends the pagination.
+	// It defaults to the rel="next" target of the Link header (RFC 5988).
+	Next func(page interface{}, res *http.Response) (string, error)
+	// CursorParam, when set, makes the value returned by Next a cursor sent in this
+	// query parameter of the first page URL instead of the next page URL
+	CursorParam string
+	// MaxPages stops after this many pages, 0 means no limit
+	MaxPages int
+}
+
+var (
+	// ErrStopPagination is returned by OnPage to stop Paginate without an error
+	ErrStopPagination = errors.New("Stop Pagination")
+)
+
+// Paginate sends the request and follows its next pages with the same method, headers,
+// cookies and credentials, calling opts.OnPage for each until there is no next page,
+// OnPage fails or ctx is done. 4xx and 5xx pages fail with *HTTPError.
+func (b *RequestBuilder) Paginate(ctx context.Context, opts PaginateOptions) error {
+	if opts.Next == nil {
+		opts.Next = nextLink
+	}
+
+	// the request header gets the cookies of the jar once sent
+	header := b.header.Clone()
+	if b.isRequestReady {
+		header.Del("Cookie")
+	}
+
+	first := ""
+	for pages := 0; opts.MaxPages <= 0 || pages < opts.MaxPages; pages++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		res := b.WithContext(ctx).response()
+		if res == nil {
+			return b.Err()
+		}
+		if first == "" {
+			first = b.req.URL.String()
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			b.fail(ErrPhaseBody, err)
+			return b.Err()
+		}
+		if len(b.errs) != 0 {
+			return b.Err()
+		}
+		if res.StatusCode >= 400 {
+			b.fail(ErrPhaseResponse, &HTTPError{
+				StatusCode: res.StatusCode,
+				Status:     res.Status,
+				Header:     res.Header,
+				Body:       body,
+			})
+			return b.Err()
+		}
+
+		var page interface{} = body
+		if opts.NewPage != nil {
+			page = opts.NewPage()
+			err = b.client.decodeJSON(bytes.NewReader(body), page)
+			if err != nil {
+				b.fail(ErrPhaseDecode, err)
+				return b.Err()
+			}
+		}
+
+		if opts.OnPage != nil {
+			err = opts.OnPage(page, res)
+			if err == ErrStopPagination {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		next, err := opts.Next(page, res)
+		if err != nil || next == "" {
+			return err
+		}
+		if opts.CursorParam != "" {
+			next, err = withQueryParam(first, opts.CursorParam, next)
+		} else {
+			next, err = resolveReference(b.req.URL.String(), next)
+		}
+		if err != nil {
+			return err
+		}
+		b = b.nextPage(next, header)
+	}
+	return nil
+}
+
+// nextPage builds the request for the page at u from b
+func (b *RequestBuilder) nextPage(u string, header http.Header) *RequestBuilder {
+	nb := b.client.NewRequest(b.method, u)
+	nb.header = header.Clone()
+	nb.cookies = b.cookies
+	nb.basic = b.basic
+	nb.digest = b.digest
+	nb.timeout = b.timeout
+	return nb
+}
+
+// resolveReference resolves ref, which may be relative, against base
+func resolveReference(base, ref string) (string, error) {
+	bu, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ru, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return bu.ResolveReference(ru).String(), nil
+}
+
+// withQueryParam sets the query parameter key of u to value
+func withQueryParam(u, key, value string) (string, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	q := pu.Query()
+	q.Set(key, value)
+	pu.RawQuery = q.Encode()
+	return pu.String(), nil
+}
+
+// nextLink returns the rel="next" target of the Link header of res
+func nextLink(_ interface{}, res *http.Response) (string, error) {
+	for _, link := range res.Header.Values("Link") {
+		for _, part := range splitLinks(link) {
+			target, params := parseLink(part)
+			for _, rel := range strings.Fields(params["rel"]) {
+				if strings.EqualFold(rel, "next") {
+					return target, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// splitLinks splits a Link header on the commas outside <> and quotes
+func splitLinks(h string) []string {
+	var (
+		parts  []string
+		start  int
+		inURI  bool
+		quoted bool
+	)
+	for i := 0; i < len(h); i++ {
+		switch c := h[i]; {
+		case c == '<' && !quoted:
+			inURI = true
+		case c == '>' && !quoted:
+			inURI = false
+		case c == '"' && !inURI:
+			quoted = !quoted
+		case c == ',' && !inURI && !quoted:
+			parts = append(parts, h[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, h[start:])
+}
+
+// parseLink parses `<target>; key=value; key="value"`
+func parseLink(s string) (string, map[string]string) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "<") {
+		return "", nil
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 0 {
+		return "", nil
+	}
+	target := s[1:end]
+	params := make(map[string]string)
+	for _, p := range strings.Split(s[end+1:], ";") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+	}
+	return target, params
+}
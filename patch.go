@@ -0,0 +1,96 @@
+package httgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// PatchOp is a single RFC 6902 JSON Patch operation
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+const (
+	MIMEJSONPatch  = "application/json-patch+json"
+	MIMEMergePatch = "application/merge-patch+json"
+)
+
+// SetJSONPatch encodes ops as an RFC 6902 JSON Patch body
+func (b *RequestBuilder) SetJSONPatch(ops []PatchOp) *RequestBuilder {
+	return b.setPatchBody(ops, MIMEJSONPatch)
+}
+
+// SetMergePatch encodes v as an RFC 7386 JSON Merge Patch body
+func (b *RequestBuilder) SetMergePatch(v interface{}) *RequestBuilder {
+	return b.setPatchBody(v, MIMEMergePatch)
+}
+
+func (b *RequestBuilder) setPatchBody(v interface{}, ct string) *RequestBuilder {
+	data, err := b.client.marshalJSON(v)
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+	b.body = bytes.NewReader(data)
+	b.bodyStream = false
+	b.SetContentType(ct)
+	return b
+}
+
+// CreateMergePatch returns the JSON Merge Patch turning original into modified
+func CreateMergePatch(original, modified interface{}) ([]byte, error) {
+	o, err := toJSONObject(original)
+	if err != nil {
+		return nil, err
+	}
+	m, err := toJSONObject(modified)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(diffObjects(o, m))
+}
+
+func toJSONObject(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	obj := make(map[string]interface{})
+	err = json.Unmarshal(data, &obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func diffObjects(o, m map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for k := range o {
+		if _, ok := m[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, mv := range m {
+		ov, ok := o[k]
+		if !ok {
+			patch[k] = mv
+			continue
+		}
+		oo, ook := ov.(map[string]interface{})
+		mo, mok := mv.(map[string]interface{})
+		if ook && mok {
+			if d := diffObjects(oo, mo); len(d) != 0 {
+				patch[k] = d
+			}
+			continue
+		}
+		if !reflect.DeepEqual(ov, mv) {
+			patch[k] = mv
+		}
+	}
+	return patch
+}
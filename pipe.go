@@ -0,0 +1,53 @@
+package httgo
+
+import (
+	"io"
+)
+
+// progressReader reports the bytes read through it to fn
+type progressReader struct {
+	r     io.Reader
+	read  int64
+	total int64
+	fn    ProgressFunc
+}
+
+// PipeTo sends b and dst with the response body of b streamed as the body of dst, without buffering,
+// e.g. c.Get(src).PipeTo(storage.Put(dst)).StatusCode() copies an object between storage systems.
+// dst is a request rather than a client since it carries the method, URL and headers of the upload,
+// it may belong to another client or to the client of b. The upload reads the download as fast as the destination accepts it,
+// OnProgress of b receives the bytes transferred. dst is sent with the Content-Length and, unless it sets
+// its own, the Content-Type of the response. Errors of b, including 4xx and 5xx responses, are reported
+// by dst, which is then not sent. The body is sent once, dst cannot replay it on redirects or retries.
+func (b *RequestBuilder) PipeTo(dst *RequestBuilder) *RequestBuilder {
+	res := b.response()
+	if res == nil || !b.downloadable(res) || !b.consume("PipeTo", false) {
+		dst.errs = append(dst.errs, b.errs...)
+		// there is nothing to send, Do returns dst as is
+		dst.isRequested = true
+		return dst
+	}
+	defer res.Body.Close()
+
+	var body io.Reader = res.Body
+	if b.progress != nil {
+		body = &progressReader{
+			r:     res.Body,
+			total: res.ContentLength,
+			fn:    b.progress,
+		}
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "" && dst.header.Get("Content-Type") == "" {
+		dst.header.Set("Content-Type", ct)
+	}
+	return dst.SetBodyStream(body, res.ContentLength).Do()
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 {
+		p.fn(p.read, p.total)
+	}
+	return n, err
+}
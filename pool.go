@@ -0,0 +1,126 @@
+package httgo
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// TransportStats is a snapshot of the connection pool
+type TransportStats struct {
+	// Open is the number of open connections, Idle the number of them waiting in the pool
+	Open  int64
+	Idle  int64
+	Hosts map[string]ConnStats
+}
+
+// ConnStats are the connection counts of a single dialed address
+type ConnStats struct {
+	Open int64
+	Idle int64
+}
+
+// SetMaxIdleConns bounds the idle connections kept across all hosts, 100 by default
+func (c *HTTPClient) SetMaxIdleConns(n int) *HTTPClient {
+	c.transport.MaxIdleConns = n
+	return c
+}
+
+// SetMaxIdleConnsPerHost bounds the idle connections kept per host, 32 by default
+func (c *HTTPClient) SetMaxIdleConnsPerHost(n int) *HTTPClient {
+	c.transport.MaxIdleConnsPerHost = n
+	return c
+}
+
+// SetMaxConnsPerHost bounds the connections per host, dialing, active and idle ones,
+// requests beyond it wait for a connection. 0 means no limit, the default.
+func (c *HTTPClient) SetMaxConnsPerHost(n int) *HTTPClient {
+	c.transport.MaxConnsPerHost = n
+	return c
+}
+
+// SetIdleConnTimeout closes pooled connections idle for longer than d, 90 seconds by default
+func (c *HTTPClient) SetIdleConnTimeout(d time.Duration) *HTTPClient {
+	c.transport.IdleConnTimeout = d
+	return c
+}
+
+// DisableKeepAlives closes every connection after its request instead of pooling it
+func (c *HTTPClient) DisableKeepAlives() *HTTPClient {
+	c.transport.DisableKeepAlives = true
+	return c
+}
+
+// EnableKeepAlives pools connections for reuse, the default
+func (c *HTTPClient) EnableKeepAlives() *HTTPClient {
+	c.transport.DisableKeepAlives = false
+	return c
+}
+
+// CloseIdleConnections closes the pooled connections which are not in use
+func (c *HTTPClient) CloseIdleConnections() {
+	c.currentTransport().CloseIdleConnections()
+	if c.h2c != nil {
+		c.h2c.closeIdleConnections()
+	}
+	if c.http3 != nil {
+		if ci, ok := c.http3.(interface{ CloseIdleConnections() }); ok {
+			ci.CloseIdleConnections()
+		}
+	}
+}
+
+// TransportStats returns the open and idle connection counts, per dialed address as well.
+// HTTP/2 connections are shared by concurrent requests and counted as idle only when unused.
+func (c *HTTPClient) TransportStats() TransportStats {
+	s := c.stats
+	ts := TransportStats{
+		Hosts: make(map[string]ConnStats),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for host, h := range s.hosts {
+		cs := ConnStats{
+			Open: atomic.LoadInt64(&h.connections),
+			Idle: atomic.LoadInt64(&h.idle),
+		}
+		if cs.Open == 0 {
+			continue
+		}
+		ts.Open += cs.Open
+		ts.Idle += cs.Idle
+		ts.Hosts[host] = cs
+	}
+	return ts
+}
+
+// connTrace tracks connections taken from and returned to the pool
+func connTrace() *httptrace.ClientTrace {
+	var conn *statsConn
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			conn = unwrapStatsConn(info.Conn)
+			if conn != nil {
+				conn.setIdle(false)
+			}
+		},
+		PutIdleConn: func(err error) {
+			if conn != nil && err == nil {
+				conn.setIdle(true)
+			}
+		},
+	}
+}
+
+func unwrapStatsConn(conn net.Conn) *statsConn {
+	if rc, ok := conn.(*rawConn); ok {
+		conn = rc.Conn
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		conn = tc.NetConn()
+	}
+	sc, _ := conn.(*statsConn)
+	return sc
+}
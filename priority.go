@@ -0,0 +1,32 @@
+package httgo
+
+import (
+	"strconv"
+)
+
+const (
+	// PriorityUrgencyHighest and PriorityUrgencyLowest bound the urgency of SetPriorityHint,
+	// PriorityUrgencyDefault is what servers assume without a Priority header
+	PriorityUrgencyHighest = 0
+	PriorityUrgencyDefault = 3
+	PriorityUrgencyLowest  = 7
+)
+
+// SetPriorityHint sets the RFC 9218 Priority header, e.g. "u=1, i": urgency from 0 (highest) to 7 (lowest),
+// out of range values are clamped, and incremental for responses usable as they arrive, such as progressive
+// images. Servers and CDNs honoring it schedule HTTP/2 and HTTP/3 streams by it; the Go transports send no
+// PRIORITY_UPDATE frames, so the header is the only signal and it reaches the origin through proxies as well.
+func (b *RequestBuilder) SetPriorityHint(urgency int, incremental bool) *RequestBuilder {
+	b.unsent("SetPriorityHint")
+	if urgency < PriorityUrgencyHighest {
+		urgency = PriorityUrgencyHighest
+	} else if urgency > PriorityUrgencyLowest {
+		urgency = PriorityUrgencyLowest
+	}
+	v := "u=" + strconv.Itoa(urgency)
+	if incremental {
+		v += ", i"
+	}
+	b.header.Set("Priority", v)
+	return b
+}
@@ -0,0 +1,125 @@
+package httgo
+
+import (
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// LatencySummary summarizes a set of durations
+type LatencySummary struct {
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+}
+
+// ProbeStats is the result of Probe
+type ProbeStats struct {
+	Requests     int
+	Errors       int
+	ErrorRate    float64
+	Total        LatencySummary
+	DNSLookup    LatencySummary
+	TCPConnect   LatencySummary
+	TLSHandshake LatencySummary
+	TTFB         LatencySummary
+	Errs         []error
+}
+
+// Probe is simple latency prober using a new client
+func Probe(u string, n int, interval time.Duration) *ProbeStats {
+	return New().Probe(u, n, interval)
+}
+
+// Probe sends n GET requests to u, interval apart, and summarizes their latency per phase.
+// Transport errors and 5xx responses count as errors.
+func (c *HTTPClient) Probe(u string, n int, interval time.Duration) *ProbeStats {
+	stats := &ProbeStats{
+		Requests: n,
+	}
+
+	var total, dns, connect, tlsHandshake, ttfb []time.Duration
+
+	for i := 0; i < n; i++ {
+		if i > 0 && interval > 0 {
+			time.Sleep(interval)
+		}
+
+		t := newTracer()
+		b := c.Get(u)
+		b.tracer = t
+		b.Do()
+		if b.res != nil {
+			io.Copy(ioutil.Discard, b.res.Body)
+		}
+		t.done()
+		errs := b.Close()
+
+		if len(errs) != 0 || b.StatusCode() >= 500 {
+			stats.Errors++
+			stats.Errs = append(stats.Errs, errs...)
+			continue
+		}
+
+		info := t.info()
+		total = append(total, info.Total)
+		ttfb = append(ttfb, info.TTFB)
+		if !info.ConnReused {
+			dns = append(dns, info.DNSLookup)
+			connect = append(connect, info.TCPConnect)
+			if info.TLSHandshake > 0 {
+				tlsHandshake = append(tlsHandshake, info.TLSHandshake)
+			}
+		}
+	}
+
+	if n > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(n)
+	}
+	stats.Total = summarize(total)
+	stats.DNSLookup = summarize(dns)
+	stats.TCPConnect = summarize(connect)
+	stats.TLSHandshake = summarize(tlsHandshake)
+	stats.TTFB = summarize(ttfb)
+
+	return stats
+}
+
+func summarize(d []time.Duration) LatencySummary {
+	if len(d) == 0 {
+		return LatencySummary{}
+	}
+
+	sort.Slice(d, func(i, j int) bool {
+		return d[i] < d[j]
+	})
+
+	var sum time.Duration
+	for _, v := range d {
+		sum += v
+	}
+
+	return LatencySummary{
+		Min:  d[0],
+		Max:  d[len(d)-1],
+		Mean: sum / time.Duration(len(d)),
+		P50:  percentile(d, 50),
+		P90:  percentile(d, 90),
+		P95:  percentile(d, 95),
+		P99:  percentile(d, 99),
+	}
+}
+
+// percentile expects sorted durations and uses the nearest-rank method
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
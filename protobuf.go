@@ -0,0 +1,73 @@
+//go:build protobuf
+// +build protobuf
+
+package httgo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeProtobuf is the Content-Type of protobuf bodies sent by SetBodyProto
+const ContentTypeProtobuf = "application/x-protobuf"
+
+var (
+	// ErrNotProtoMessage is returned by Decode for protobuf responses decoded into anything but a proto.Message
+	ErrNotProtoMessage = errors.New("Not a Proto Message")
+)
+
+// protobuf support is only available when building with the protobuf tag
+func init() {
+	for _, ct := range []string{ContentTypeProtobuf, "application/protobuf", "application/vnd.google.protobuf"} {
+		decoders[ct] = decodeProto
+	}
+}
+
+// SetBodyProto encodes m as the protobuf request body
+func (b *RequestBuilder) SetBodyProto(m proto.Message) *RequestBuilder {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+	b.body = bytes.NewReader(data)
+	b.bodyStream = false
+	if b.header.Get("Content-Type") == "" {
+		b.header.Set("Content-Type", ContentTypeProtobuf)
+	}
+	return b
+}
+
+// DecodeProto decodes the protobuf response body into m whatever its Content-Type,
+// Decode also handles protobuf responses by their Content-Type
+func (b *RequestBuilder) DecodeProto(m proto.Message) *RequestBuilder {
+	res := b.response()
+	if res == nil {
+		return b
+	}
+	if !b.consume("DecodeProto", false) {
+		return b
+	}
+	defer res.Body.Close()
+	err := decodeProto(res.Body, m)
+	if err != nil {
+		b.fail(ErrPhaseDecode, err)
+	}
+	return b
+}
+
+func decodeProto(r io.Reader, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, m)
+}
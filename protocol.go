@@ -0,0 +1,96 @@
+package httgo
+
+import (
+	"net/http"
+	"sync"
+)
+
+// h2cTransport is the transport of http:// URLs after EnableH2C, a clone of the client transport
+// speaking only HTTP/2, net/http does not use prior knowledge while HTTP/1 is enabled
+type h2cTransport struct {
+	mu   sync.Mutex
+	base *http.Transport
+	t    *http.Transport
+}
+
+// EnableHTTP2 negotiates HTTP/2 over TLS when the server supports it, falling back to HTTP/1.1.
+// Protocol settings must be made before the first request.
+func (c *HTTPClient) EnableHTTP2() *HTTPClient {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	c.transport.Protocols = p
+	c.transport.ForceAttemptHTTP2 = true
+	return c
+}
+
+// DisableHTTP2 forbids HTTP/2, every request uses HTTP/1.1
+func (c *HTTPClient) DisableHTTP2() *HTTPClient {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	c.transport.Protocols = p
+	c.transport.ForceAttemptHTTP2 = false
+	return c
+}
+
+// EnableH2C speaks cleartext HTTP/2 with prior knowledge to http:// URLs,
+// https:// URLs keep negotiating HTTP/2 over TLS with HTTP/1.1 as fallback. Servers must support h2c.
+func (c *HTTPClient) EnableH2C() *HTTPClient {
+	c.EnableHTTP2()
+	c.h2c = new(h2cTransport)
+	return c
+}
+
+// transport returns the h2c clone of base, made again when base was replaced, see ResetTransport
+func (h *h2cTransport) transport(base *http.Transport) *http.Transport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.base != base {
+		if h.t != nil {
+			h.t.CloseIdleConnections()
+		}
+		p := new(http.Protocols)
+		p.SetUnencryptedHTTP2(true)
+		h.t = base.Clone()
+		h.t.Protocols = p
+		h.base = base
+	}
+	return h.t
+}
+
+func (h *h2cTransport) closeIdleConnections() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.t != nil {
+		h.t.CloseIdleConnections()
+	}
+}
+
+// Proto returns the protocol the response was received with, e.g. "HTTP/1.1", "HTTP/2.0" or "HTTP/3.0"
+func (b *RequestBuilder) Proto() string {
+	res := b.response()
+	if res == nil {
+		return ""
+	}
+	return res.Proto
+}
+
+// baseRoundTrip sends req to the mock transport or recorder when set, or over the network
+func (c *HTTPClient) baseRoundTrip(req *http.Request) (*http.Response, error) {
+	if c.stub != nil {
+		return c.stub.RoundTrip(req)
+	}
+	return c.networkRoundTrip(req)
+}
+
+// networkRoundTrip sends req over the network with the HTTP/3 transport when enabled
+func (c *HTTPClient) networkRoundTrip(req *http.Request) (*http.Response, error) {
+	if c.http3 != nil {
+		if c.ssrfProtection {
+			// quic-go resolves and dials on its own, the addresses cannot be checked
+			return nil, ErrSSRFOverHTTP3
+		}
+		return c.http3.RoundTrip(req)
+	}
+	return c.roundTripFresh(req)
+}
@@ -0,0 +1,36 @@
+package httgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnableH2C(t *testing.T) {
+	h2c := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h2c.Config.Protocols = new(http.Protocols)
+	h2c.Config.Protocols.SetHTTP1(true)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+
+	// a TLS server without HTTP/2
+	tls := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tls.Close()
+
+	c := New().InsecureSkipVerify().EnableH2C()
+	tests := []struct {
+		url   string
+		proto string
+	}{
+		{h2c.URL, "HTTP/2.0"},
+		{tls.URL, "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		b := c.Get(tt.url)
+		if proto := b.Proto(); proto != tt.proto {
+			t.Fatalf("%s: proto = %q, want %q (errors %v)", tt.url, proto, tt.proto, b.GetErrors())
+		}
+		b.Close()
+	}
+}
@@ -0,0 +1,183 @@
+package httgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxProxyAuthLegs bounds the 407 answers of a request, NTLM handshakes take two
+const maxProxyAuthLegs = 3
+
+// ProxyAuthFunc returns the Proxy-Authorization value answering challenges, the Proxy-Authenticate
+// headers of a 407 response such as `Basic realm="corp"`, "Negotiate" and "NTLM", or a single
+// "NTLM <token>" for the next leg of a handshake. For HTTPS tunnels the challenges are those of
+// the response to the CONNECT request, the value is then sent with the next CONNECT request.
+type ProxyAuthFunc func(ctx context.Context, proxy *url.URL, challenges []string) (string, error)
+
+// proxyAuth answers proxy challenges and remembers the last single-leg credentials of each proxy
+type proxyAuth struct {
+	fn    ProxyAuthFunc
+	mu    sync.Mutex
+	creds map[string]string
+}
+
+// proxyChallenge fails CONNECT requests answered with 407, see connectResponse
+type proxyChallenge struct {
+	challenges []string
+}
+
+// SetProxyAuth answers 407 Proxy Authentication Required responses with the credentials of fn
+// and resends the request through the proxy, answering up to 3 challenges for multi-leg schemes.
+// Credentials of single-leg schemes are sent upfront to the same proxy afterwards. NTLM needs the handshake to stay
+// on one connection, which holds for plain HTTP requests but not for HTTPS tunnels, where only
+// single-leg schemes such as Basic or Kerberos Negotiate work. Bodies must be replayable.
+func (c *HTTPClient) SetProxyAuth(fn ProxyAuthFunc) *HTTPClient {
+	if fn == nil {
+		c.proxyAuth = nil
+		c.transport.GetProxyConnectHeader = nil
+		c.transport.OnProxyConnectResponse = nil
+		return c
+	}
+	c.proxyAuth = &proxyAuth{
+		fn:    fn,
+		creds: make(map[string]string),
+	}
+	c.transport.GetProxyConnectHeader = c.proxyAuth.connectHeader
+	c.transport.OnProxyConnectResponse = c.proxyAuth.connectResponse
+	return c
+}
+
+func (e *proxyChallenge) Error() string {
+	return http.StatusText(http.StatusProxyAuthRequired)
+}
+
+// connectHeader adds the credentials of proxyURL to CONNECT requests
+func (p *proxyAuth) connectHeader(ctx context.Context, proxyURL *url.URL, target string) (http.Header, error) {
+	auth := p.credentials(proxyURL)
+	if auth == "" {
+		return nil, nil
+	}
+	return http.Header{
+		"Proxy-Authorization": {auth},
+	}, nil
+}
+
+// connectResponse turns a 407 answer to a CONNECT request into a proxyChallenge, net/http
+// otherwise only reports the status text
+func (p *proxyAuth) connectResponse(ctx context.Context, proxyURL *url.URL, connectReq *http.Request, connectRes *http.Response) error {
+	if connectRes.StatusCode != http.StatusProxyAuthRequired {
+		return nil
+	}
+	return &proxyChallenge{
+		challenges: connectRes.Header.Values("Proxy-Authenticate"),
+	}
+}
+
+func (p *proxyAuth) credentials(proxy *url.URL) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.creds[proxy.Host]
+}
+
+// roundTrip sends req with next, answering the challenges of proxy
+func (p *proxyAuth) roundTrip(req *http.Request, proxy *url.URL, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	tunnel := req.URL.Scheme == "https"
+	if !tunnel && req.Header.Get("Proxy-Authorization") == "" {
+		if auth := p.credentials(proxy); auth != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("Proxy-Authorization", auth)
+		}
+	}
+
+	res, err := next(req)
+	for leg := 0; leg < maxProxyAuthLegs; leg++ {
+		var challenges []string
+		var pc *proxyChallenge
+		switch {
+		case err == nil && res.StatusCode == http.StatusProxyAuthRequired:
+			challenges = res.Header.Values("Proxy-Authenticate")
+		case err != nil && tunnel && errors.As(err, &pc):
+			challenges = pc.challenges
+		default:
+			return res, err
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return res, err
+		}
+
+		auth, aerr := p.fn(req.Context(), proxy, challenges)
+		if aerr != nil {
+			if res != nil {
+				res.Body.Close()
+			}
+			return nil, aerr
+		}
+		single := singleLegAuth(auth)
+		p.mu.Lock()
+		if single {
+			p.creds[proxy.Host] = auth
+		} else {
+			delete(p.creds, proxy.Host)
+		}
+		p.mu.Unlock()
+		if tunnel && !single {
+			// every CONNECT request opens a new connection, handshakes cannot carry on
+			return nil, err
+		}
+
+		areq := req.Clone(req.Context())
+		if req.GetBody != nil {
+			areq.Body, aerr = req.GetBody()
+			if aerr != nil {
+				if res != nil {
+					res.Body.Close()
+				}
+				return nil, aerr
+			}
+		}
+		if !tunnel {
+			areq.Header.Set("Proxy-Authorization", auth)
+		}
+		if res != nil {
+			// keep the connection, connection-oriented schemes continue the handshake on it
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+			res.Body.Close()
+		}
+		req = areq
+		res, err = next(req)
+	}
+	return res, err
+}
+
+// singleLegAuth reports whether the Proxy-Authorization value auth may be sent again as is,
+// which is not the case for NTLM tokens, also when wrapped by Negotiate, bound to a handshake
+func singleLegAuth(auth string) bool {
+	scheme, token, _ := strings.Cut(auth, " ")
+	switch strings.ToLower(scheme) {
+	case "ntlm":
+		return false
+	case "negotiate":
+		// base64 of the "NTLMSSP" signature
+		return !strings.HasPrefix(strings.TrimSpace(token), "TlRMTVNTUA")
+	}
+	return true
+}
+
+// proxyFor returns the proxy req is sent through, nil for direct requests
+func (c *HTTPClient) proxyFor(req *http.Request) *url.URL {
+	proxy := c.currentTransport().Proxy
+	if proxy == nil {
+		return nil
+	}
+	u, err := proxy(req)
+	if err != nil {
+		return nil
+	}
+	return u
+}
@@ -0,0 +1,110 @@
+package httgo
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestProxyAuthCachesSingleLegSchemes(t *testing.T) {
+	tests := []struct {
+		name     string
+		legs     []string
+		upfront  bool
+		requests int
+	}{
+		{"basic", []string{"Basic dTpw"}, true, 3},
+		{"ntlm", []string{"NTLM TlRMTVNTUAAB", "NTLM TlRMTVNTUAAD"}, false, 6},
+		{"ntlm over negotiate", []string{"Negotiate TlRMTVNTUAAB", "Negotiate TlRMTVNTUAAD"}, false, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var seen []string
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth := r.Header.Get("Proxy-Authorization")
+				mu.Lock()
+				seen = append(seen, auth)
+				mu.Unlock()
+				if auth != tt.legs[len(tt.legs)-1] {
+					w.Header().Set("Proxy-Authenticate", "Challenge")
+					w.WriteHeader(http.StatusProxyAuthRequired)
+				}
+			}))
+			defer proxy.Close()
+
+			fn := func(ctx context.Context, u *url.URL, challenges []string) (string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				for i, leg := range tt.legs {
+					if seen[len(seen)-1] == leg {
+						return tt.legs[i+1], nil
+					}
+				}
+				return tt.legs[0], nil
+			}
+
+			c := New().SetProxy(proxy.URL).SetProxyAuth(fn)
+			for i := 0; i < 2; i++ {
+				if code, errs := c.Get("http://example.com/").StatusOnly(); code != http.StatusOK || len(errs) != 0 {
+					t.Fatalf("request %d: %d %v", i, code, errs)
+				}
+			}
+			if len(seen) != tt.requests {
+				t.Fatalf("proxy saw %q, want %d requests", seen, tt.requests)
+			}
+			if second := seen[len(tt.legs)+1]; (second != "") != tt.upfront {
+				t.Fatalf("second request started with %q, upfront = %v", second, tt.upfront)
+			}
+		})
+	}
+}
+
+func TestProxyAuthTunnelChallenges(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic dTpw" {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="corp"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		dst, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		go func() {
+			io.Copy(dst, buf)
+			dst.Close()
+		}()
+		io.Copy(conn, dst)
+		conn.Close()
+	}))
+	defer proxy.Close()
+
+	var got [][]string
+	c := New().InsecureSkipVerify().SetProxy(proxy.URL).SetProxyAuth(func(ctx context.Context, u *url.URL, challenges []string) (string, error) {
+		got = append(got, challenges)
+		return "Basic dTpw", nil
+	})
+	if code, errs := c.Get(target.URL).StatusOnly(); code != http.StatusOK || len(errs) != 0 {
+		t.Fatalf("got %d %v", code, errs)
+	}
+	if want := [][]string{{`Basic realm="corp"`}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("challenges = %q, want %q", got, want)
+	}
+}
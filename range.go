@@ -0,0 +1,175 @@
+package httgo
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// RangeAssembler combines 206 Partial Content responses into a single resource,
+// validating that every part matches the requested range and the resource size
+type RangeAssembler struct {
+	w     io.WriterAt
+	total int64
+	mu    sync.Mutex
+	parts []byteRange
+}
+
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// SupportsRange is simple Range support probe using a new client
+func SupportsRange(u string) (bool, int64, error) {
+	return New().SupportsRange(u)
+}
+
+// SupportsRange probes u with a GET of its first byte and reports whether the server
+// answers with a valid 206, and the resource size (-1 when unknown)
+func (c *HTTPClient) SupportsRange(u string) (bool, int64, error) {
+	b := c.Get(u).SetHeader("Range", []string{"bytes=0-0"}).Do()
+	res := b.response()
+	if err := requestError(b.Close()); err != nil || res == nil {
+		return false, -1, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		start, _, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil || start != 0 {
+			return false, -1, ErrInvalidContentRange
+		}
+		return true, total, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// only an empty resource cannot satisfy its first byte, "bytes */0"
+		total, err := unsatisfiedRangeTotal(res.Header.Get("Content-Range"))
+		if err != nil {
+			return false, -1, err
+		}
+		return true, total, nil
+	}
+	if res.StatusCode/100 == 2 {
+		return false, res.ContentLength, nil
+	}
+	return false, -1, &HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+	}
+}
+
+// NewRangeAssembler writes parts of a resource of total bytes into w, -1 when the size is unknown
+func NewRangeAssembler(w io.WriterAt, total int64) *RangeAssembler {
+	return &RangeAssembler{
+		w:     w,
+		total: total,
+	}
+}
+
+// WritePart copies res, the response to a request for bytes start-end, to its offset and closes it.
+// The server may shorten the range but it must start at start, agree on the resource size and
+// deliver exactly the announced bytes. It is safe to call from multiple goroutines.
+func (a *RangeAssembler) WritePart(res *http.Response, start, end int64) (int64, error) {
+	defer res.Body.Close()
+
+	pend, total, err := checkPartialContent(res, start)
+	if err != nil {
+		return 0, err
+	}
+	if pend > end {
+		return 0, ErrInvalidContentRange
+	}
+
+	a.mu.Lock()
+	switch {
+	case a.total < 0:
+		a.total = total
+	case total >= 0 && total != a.total:
+		a.mu.Unlock()
+		return 0, ErrInvalidContentRange
+	}
+	a.mu.Unlock()
+
+	size := pend - start + 1
+	n, err := io.Copy(io.NewOffsetWriter(a.w, start), io.LimitReader(res.Body, size))
+	if err != nil {
+		return n, err
+	}
+	if n != size {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	a.mu.Lock()
+	a.parts = append(a.parts, byteRange{
+		start: start,
+		end:   pend,
+	})
+	a.mu.Unlock()
+	return n, nil
+}
+
+// Missing returns the "start-end" ranges not written yet, nil once the resource is complete.
+// It returns nil as well while the size is unknown and nothing is missing before the last part.
+func (a *RangeAssembler) Missing() []string {
+	a.mu.Lock()
+	parts := append([]byteRange(nil), a.parts...)
+	total := a.total
+	a.mu.Unlock()
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].start < parts[j].start
+	})
+
+	var missing []string
+	var next int64
+	for _, p := range parts {
+		if p.start > next {
+			missing = append(missing, strconv.FormatInt(next, 10)+"-"+strconv.FormatInt(p.start-1, 10))
+		}
+		if p.end+1 > next {
+			next = p.end + 1
+		}
+	}
+	if total >= 0 && next < total {
+		missing = append(missing, strconv.FormatInt(next, 10)+"-"+strconv.FormatInt(total-1, 10))
+	}
+	return missing
+}
+
+// Complete reports whether every byte of a resource of known size was written
+func (a *RangeAssembler) Complete() bool {
+	a.mu.Lock()
+	known := a.total >= 0
+	a.mu.Unlock()
+	return known && len(a.Missing()) == 0
+}
+
+// checkPartialContent validates that res is a 206 whose Content-Range starts at start
+func checkPartialContent(res *http.Response, start int64) (end, total int64, err error) {
+	if res.StatusCode != http.StatusPartialContent {
+		return 0, 0, ErrInvalidContentRange
+	}
+	pstart, end, total, err := parseContentRange(res.Header.Get("Content-Range"))
+	if err != nil || pstart != start {
+		return 0, 0, ErrInvalidContentRange
+	}
+	if res.ContentLength >= 0 && res.ContentLength != end-start+1 {
+		return 0, 0, ErrInvalidContentRange
+	}
+	return end, total, nil
+}
+
+// unsatisfiedRangeTotal parses the "bytes */total" Content-Range of a 416 response
+func unsatisfiedRangeTotal(cr string) (int64, error) {
+	if len(cr) < len("bytes */") || cr[:len("bytes */")] != "bytes */" {
+		return -1, ErrInvalidContentRange
+	}
+	total, err := strconv.ParseInt(cr[len("bytes */"):], 10, 64)
+	if err != nil || total < 0 {
+		return -1, ErrInvalidContentRange
+	}
+	return total, nil
+}
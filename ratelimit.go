@@ -0,0 +1,174 @@
+package httgo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateLimiter is a token bucket refilled at rate tokens per second up to burst
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// hostLimiters holds the per-host token buckets
+type hostLimiters struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[string]*rateLimiter
+}
+
+// SetRateLimit limits the client to rps requests per second with bursts of burst,
+// requests wait for a token before being sent or fail when their context is done
+func (c *HTTPClient) SetRateLimit(rps float64, burst int) *HTTPClient {
+	if rps <= 0 {
+		c.limiter = nil
+		return c
+	}
+	c.limiter = newRateLimiter(rps, burst)
+	return c
+}
+
+// SetPerHostRateLimit limits every host to rps requests per second with bursts of burst
+func (c *HTTPClient) SetPerHostRateLimit(rps float64, burst int) *HTTPClient {
+	c.hostLimits().setDefault(rps, burst)
+	return c
+}
+
+// SetHostRateLimit limits requests to host (as in URL.Host) to rps requests per second.
+// rps <= 0 removes the limit of host, which then falls back to SetPerHostRateLimit.
+func (c *HTTPClient) SetHostRateLimit(host string, rps float64, burst int) *HTTPClient {
+	hl := c.hostLimits()
+	hl.mu.Lock()
+	if rps <= 0 {
+		delete(hl.limiters, host)
+	} else {
+		hl.limiters[host] = newRateLimiter(rps, burst)
+	}
+	hl.mu.Unlock()
+	return c
+}
+
+func (c *HTTPClient) hostLimits() *hostLimiters {
+	if c.hostLimiters == nil {
+		c.hostLimiters = &hostLimiters{
+			limiters: make(map[string]*rateLimiter),
+		}
+	}
+	return c.hostLimiters
+}
+
+func (c *HTTPClient) waitRateLimit(req *http.Request) error {
+	if c.limiter == nil && c.hostLimiters == nil && c.rateStore == nil {
+		return nil
+	}
+	atomic.AddInt64(&c.stats.queued, 1)
+	defer atomic.AddInt64(&c.stats.queued, -1)
+
+	ctx := req.Context()
+	host := req.URL.Host
+	hl := c.hostLimiter(host)
+	if c.rateStore != nil {
+		err := c.rateStore.waitCooldown(ctx, host, hl)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.limiter != nil {
+		if c.rateStore != nil {
+			c.rateStore.load("*", c.limiter)
+		}
+		err := c.limiter.wait(ctx)
+		if err != nil {
+			return err
+		}
+		if c.rateStore != nil {
+			c.rateStore.save("*", c.limiter)
+		}
+	}
+	if hl != nil {
+		err := hl.wait(ctx)
+		if err != nil {
+			return err
+		}
+		if c.rateStore != nil {
+			c.rateStore.save(host, hl)
+		}
+	}
+	return nil
+}
+
+// hostLimiter returns the token bucket of host, nil when it is not limited
+func (c *HTTPClient) hostLimiter(host string) *rateLimiter {
+	if c.hostLimiters == nil {
+		return nil
+	}
+	return c.hostLimiters.get(host)
+}
+
+func (h *hostLimiters) setDefault(rps float64, burst int) {
+	h.mu.Lock()
+	h.rate = rps
+	h.burst = burst
+	h.mu.Unlock()
+}
+
+func (h *hostLimiters) get(host string) *rateLimiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l, ok := h.limiters[host]
+	if !ok && h.rate > 0 {
+		l = newRateLimiter(h.rate, h.burst)
+		h.limiters[host] = l
+	}
+	return l
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	tokens := l.tokens
+	l.mu.Unlock()
+
+	if tokens >= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(time.Duration(-tokens / l.rate * float64(time.Second)))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
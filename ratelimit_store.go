@@ -0,0 +1,141 @@
+package httgo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitKeyPrefix prefixes the CacheStore keys of persisted rate limits,
+// followed by the host or "*" for the client wide limit
+const rateLimitKeyPrefix = "httgo:ratelimit:"
+
+// rateLimitStore persists token buckets and Retry-After cooldowns, see PersistRateLimits
+type rateLimitStore struct {
+	store   CacheStore
+	mu      sync.Mutex
+	entries map[string]*rateLimitEntry
+}
+
+// rateLimitEntry is the state of a key loaded from the store
+type rateLimitEntry struct {
+	cooldown time.Time
+}
+
+// rateLimitState is the persisted form of a key
+type rateLimitState struct {
+	Tokens   *float64 `json:"tokens,omitempty"`
+	Last     int64    `json:"last,omitempty"`
+	Cooldown int64    `json:"cooldown,omitempty"`
+}
+
+// PersistRateLimits keeps the token buckets of SetRateLimit and the per-host limits in store,
+// along with cooldowns requested by 429 and 503 responses through Retry-After, so short-lived
+// processes and restarted pods sharing store carry on with the budgets left instead of
+// re-violating upstream limits. State is loaded on the first request to each host and saved after each.
+func (c *HTTPClient) PersistRateLimits(store CacheStore) *HTTPClient {
+	if store == nil {
+		c.rateStore = nil
+		return c
+	}
+	c.rateStore = &rateLimitStore{
+		store:   store,
+		entries: make(map[string]*rateLimitEntry),
+	}
+	return c
+}
+
+// load returns the entry of key, restoring the tokens of l on first use
+func (s *rateLimitStore) load(key string, l *rateLimiter) *rateLimitEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if ok {
+		return e
+	}
+	e = new(rateLimitEntry)
+	s.entries[key] = e
+
+	data, ok := s.store.Get(rateLimitKeyPrefix + key)
+	if !ok {
+		return e
+	}
+	var st rateLimitState
+	if json.Unmarshal(data, &st) != nil {
+		return e
+	}
+	if st.Cooldown != 0 {
+		e.cooldown = time.Unix(0, st.Cooldown)
+	}
+	if l != nil && st.Tokens != nil {
+		l.mu.Lock()
+		l.tokens = *st.Tokens
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = time.Unix(0, st.Last)
+		l.mu.Unlock()
+	}
+	return e
+}
+
+// save stores the tokens of l, which may be nil, and the cooldown of key
+func (s *rateLimitStore) save(key string, l *rateLimiter) {
+	var st rateLimitState
+	if l != nil {
+		l.mu.Lock()
+		tokens := l.tokens
+		st.Tokens = &tokens
+		st.Last = l.last.UnixNano()
+		l.mu.Unlock()
+	}
+	s.mu.Lock()
+	if e, ok := s.entries[key]; ok && !e.cooldown.IsZero() {
+		st.Cooldown = e.cooldown.UnixNano()
+	}
+	s.mu.Unlock()
+
+	data, err := json.Marshal(st)
+	if err == nil {
+		s.store.Set(rateLimitKeyPrefix+key, data)
+	}
+}
+
+// waitCooldown blocks until the cooldown of host is over or ctx is done
+func (s *rateLimitStore) waitCooldown(ctx context.Context, host string, l *rateLimiter) error {
+	e := s.load(host, l)
+	s.mu.Lock()
+	d := time.Until(e.cooldown)
+	s.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// coolDown records the Retry-After of a 429 or 503 response of host
+func (s *rateLimitStore) coolDown(res *http.Response, host string, l *rateLimiter) {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+	d, ok := retryAfter(res.Header)
+	if !ok || d <= 0 {
+		return
+	}
+	e := s.load(host, l)
+	s.mu.Lock()
+	if until := time.Now().Add(d); until.After(e.cooldown) {
+		e.cooldown = until
+	}
+	s.mu.Unlock()
+	s.save(host, l)
+}
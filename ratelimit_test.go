@@ -0,0 +1,30 @@
+package httgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSetHostRateLimitDisabled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	c := New().SetHostRateLimit(u.Host, 1, 1).SetHostRateLimit(u.Host, 0, 0)
+	if c.hostLimiter(u.Host) != nil {
+		t.Fatal("host still limited after rps 0")
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if errs := c.Get(srv.URL).Do().Close(); len(errs) != 0 {
+			t.Fatal(errs)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("unlimited requests took %v", d)
+	}
+}
@@ -0,0 +1,222 @@
+package httgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+)
+
+// maxRawHeaderBytes matches the default response header limit of http.Transport
+const maxRawHeaderBytes = 1 << 20
+
+// rawConn records the bytes read for the response header of the request it is armed for
+type rawConn struct {
+	net.Conn
+	mu      sync.Mutex
+	capture *rawCapture
+}
+
+// rawCapture collects the header lines of one response, 1xx responses skipped
+type rawCapture struct {
+	mu    sync.Mutex
+	buf   []byte
+	lines []string
+	done  bool
+}
+
+// rawHeaderTrace arms the connection of each attempt, the last one holds the final response
+type rawHeaderTrace struct {
+	mu      sync.Mutex
+	conn    *rawConn
+	capture *rawCapture
+}
+
+// EnableRawHeaders captures the response header lines as received, before canonicalization,
+// see RequestBuilder.RawHeaders. Capturing dials TLS itself and speaks HTTP/1.1 only,
+// HTTP/2 lowercases header names on the wire anyway. It must be enabled before the first request.
+func (c *HTTPClient) EnableRawHeaders() *HTTPClient {
+	c.rawHeaders = true
+	c.transport.DialTLSContext = c.dialTLSRaw
+	return c.DisableHTTP2()
+}
+
+// RawHeaders returns the header lines of the response exactly as received, e.g. "x-request-id: 1",
+// in order and without the status line. It is nil unless the client uses EnableRawHeaders,
+// and for cached, mocked and proxied HTTPS responses.
+func (b *RequestBuilder) RawHeaders() []string {
+	if b.response() == nil || b.raw == nil {
+		return nil
+	}
+	return b.raw.lines()
+}
+
+// dialTLSRaw performs the TLS handshake of the transport on a connection whose
+// plaintext is captured, so res.TLS is restored by rawHeaderTrace
+func (c *HTTPClient) dialTLSRaw(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := c.dialResolved(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	conn = c.stats.conn(addr, conn)
+
+	config := c.transport.TLSClientConfig.Clone()
+	if config == nil {
+		config = new(tls.Config)
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		config.ServerName = host
+	}
+	config.NextProtos = []string{"http/1.1"}
+
+	if d := c.transport.TLSHandshakeTimeout; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	tc := tls.Client(conn, config)
+	err = tc.HandshakeContext(ctx)
+	if err != nil {
+		tc.Close()
+		return nil, err
+	}
+	return &rawConn{
+		Conn: tc,
+	}, nil
+}
+
+func (rc *rawConn) Read(p []byte) (int, error) {
+	n, err := rc.Conn.Read(p)
+	if n > 0 {
+		rc.mu.Lock()
+		capture := rc.capture
+		rc.mu.Unlock()
+		if capture != nil {
+			capture.write(p[:n])
+		}
+	}
+	return n, err
+}
+
+// arm starts capturing the next response read from the connection
+func (rc *rawConn) arm() *rawCapture {
+	capture := new(rawCapture)
+	rc.mu.Lock()
+	rc.capture = capture
+	rc.mu.Unlock()
+	return capture
+}
+
+func (rc *rawConn) tlsState() *tls.ConnectionState {
+	tc, ok := rc.Conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tc.ConnectionState()
+	return &state
+}
+
+func (rc *rawCapture) write(p []byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.done {
+		return
+	}
+	rc.buf = append(rc.buf, p...)
+	for {
+		end, next := rawHeaderEnd(rc.buf)
+		if end < 0 {
+			if len(rc.buf) > maxRawHeaderBytes {
+				rc.done = true
+				rc.buf = nil
+			}
+			return
+		}
+		lines := strings.Split(string(rc.buf[:end]), "\n")
+		rc.buf = rc.buf[next:]
+		if informational(lines[0]) {
+			continue
+		}
+		for _, line := range lines[1:] {
+			rc.lines = append(rc.lines, strings.TrimSuffix(line, "\r"))
+		}
+		rc.done = true
+		rc.buf = nil
+		return
+	}
+}
+
+// rawHeaderEnd returns the end of the first header block in buf and the start of what follows it,
+// -1 while incomplete. Bare LF line endings are accepted as by net/textproto.
+func rawHeaderEnd(buf []byte) (int, int) {
+	for i := bytes.IndexByte(buf, '\n'); i >= 0; {
+		rest := buf[i+1:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("\r\n")):
+			return i, i + 3
+		case bytes.HasPrefix(rest, []byte("\n")):
+			return i, i + 2
+		}
+		j := bytes.IndexByte(rest, '\n')
+		if j < 0 {
+			break
+		}
+		i += j + 1
+	}
+	return -1, -1
+}
+
+// informational reports 1xx status lines which precede the final response, 101 ends the exchange
+func informational(status string) bool {
+	f := strings.Fields(status)
+	return len(f) > 1 && len(f[1]) == 3 && f[1][0] == '1' && f[1] != "101"
+}
+
+func (t *rawHeaderTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			rc, ok := info.Conn.(*rawConn)
+			if !ok {
+				return
+			}
+			t.mu.Lock()
+			t.conn = rc
+			t.capture = rc.arm()
+			t.mu.Unlock()
+		},
+	}
+}
+
+func (t *rawHeaderTrace) lines() []string {
+	t.mu.Lock()
+	capture := t.capture
+	t.mu.Unlock()
+	if capture == nil {
+		return nil
+	}
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	if !capture.done {
+		return nil
+	}
+	return append([]string(nil), capture.lines...)
+}
+
+// restoreTLS sets the connection state http.Transport leaves out for connections from DialTLSContext
+func (t *rawHeaderTrace) restoreTLS(res *http.Response) {
+	t.mu.Lock()
+	conn := t.conn
+	t.mu.Unlock()
+	if res.TLS == nil && conn != nil && res.Request != nil && res.Request.URL.Scheme == "https" {
+		res.TLS = conn.tlsState()
+	}
+}
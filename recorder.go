@@ -0,0 +1,186 @@
+package httgo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"unicode/utf8"
+)
+
+// RecordMode selects how a Recorder treats its fixture
+type RecordMode int
+
+const (
+	// RecordModeAuto replays an existing fixture and records when it does not exist yet
+	RecordModeAuto RecordMode = iota
+	// RecordModeRecord always sends requests to the network and records them
+	RecordModeRecord
+	// RecordModeReplay only answers from the fixture, e.g. in CI
+	RecordModeReplay
+)
+
+// Recorder captures live responses into a JSON fixture and replays them, like VCR
+type Recorder struct {
+	path         string
+	replay       bool
+	next         http.RoundTripper
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestHeader  http.Header `json:"request_header,omitempty"`
+	RequestBody    fixtureBody `json:"request_body,omitempty"`
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   fixtureBody `json:"response_body,omitempty"`
+}
+
+// fixtureBody is stored as text when it is valid UTF-8 and as base64 otherwise
+type fixtureBody []byte
+
+type fixture struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+type encodedBody struct {
+	Base64 string `json:"base64"`
+}
+
+// ErrNoRecordedInteraction is returned in replay mode for requests missing from the fixture
+var ErrNoRecordedInteraction = errors.New("No Recorded Interaction")
+
+// NewRecorder returns a Recorder for the fixture at path
+func NewRecorder(path string, mode RecordMode) (*Recorder, error) {
+	r := &Recorder{
+		path: path,
+	}
+
+	if mode == RecordModeRecord {
+		return r, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && mode == RecordModeAuto {
+			return r, nil
+		}
+		return nil, err
+	}
+
+	var f fixture
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, err
+	}
+	r.replay = true
+	r.interactions = f.Interactions
+	r.used = make([]bool, len(f.Interactions))
+	return r, nil
+}
+
+// SetRecorder records or replays every request with r, see SetMockTransport
+func (c *HTTPClient) SetRecorder(r *Recorder) *HTTPClient {
+	r.next = roundTripperFunc(c.networkRoundTrip)
+	c.stub = r
+	return c
+}
+
+// Save writes the recorded interactions to the fixture, it does nothing when replaying
+func (r *Recorder) Save() error {
+	if r.replay {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(fixture{
+		Interactions: r.interactions,
+	}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, data, 0644)
+}
+
+// RoundTrip replays the first unused interaction matching req, or sends and records it
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.replay {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, in := range r.interactions {
+			if !r.used[i] && in.Method == req.Method && sameURL(req, in.URL) && bytes.Equal(in.RequestBody, body) {
+				r.used[i] = true
+				return newResponse(req, in.StatusCode, in.ResponseHeader.Clone(), in.ResponseBody), nil
+			}
+		}
+		return nil, ErrNoRecordedInteraction
+	}
+
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeader:  maskHeader(req.Header),
+		RequestBody:    body,
+		StatusCode:     res.StatusCode,
+		ResponseHeader: res.Header.Clone(),
+		ResponseBody:   data,
+	})
+	r.mu.Unlock()
+	return res, nil
+}
+
+func (b fixtureBody) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(encodedBody{
+		Base64: base64.StdEncoding.EncodeToString(b),
+	})
+}
+
+func (b *fixtureBody) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*b = []byte(s)
+		return nil
+	}
+	var e encodedBody
+	err := json.Unmarshal(data, &e)
+	if err != nil {
+		return err
+	}
+	*b, err = base64.StdEncoding.DecodeString(e.Base64)
+	return err
+}
@@ -0,0 +1,91 @@
+package httgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// redactedValue replaces masked headers and JSON fields
+const redactedValue = "***"
+
+// SetRedactionPaths masks JSON fields of request and response bodies in debug logs, HAR files and
+// archive records, the traffic the client sends and receives is not changed. Paths are dot separated
+// keys, "[]" stands for every element of an array and "*" for every key, with an optional "$." prefix,
+// e.g. "user.email", "items[].card.number" or "*.password". Matching values become "***".
+// JSON bodies which do not parse, e.g. truncated ones, are replaced entirely, other bodies are kept.
+func (c *HTTPClient) SetRedactionPaths(jsonPaths ...string) *HTTPClient {
+	c.redactPaths = nil
+	for _, p := range jsonPaths {
+		p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+		p = strings.Replace(strings.Replace(p, "[*]", "[]", -1), "[]", ".[]", -1)
+		var path []string
+		for _, seg := range strings.Split(p, ".") {
+			if seg != "" {
+				path = append(path, seg)
+			}
+		}
+		if len(path) > 0 {
+			c.redactPaths = append(c.redactPaths, path)
+		}
+	}
+	return c
+}
+
+// redactBody returns body with the fields of SetRedactionPaths masked
+func (c *HTTPClient) redactBody(body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(c.redactPaths) == 0 || len(trimmed) == 0 || trimmed[0] != '{' && trimmed[0] != '[' {
+		return body
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []byte(redactedValue)
+	}
+	redacted := false
+	for _, path := range c.redactPaths {
+		var ok bool
+		v, ok = redactJSON(v, path)
+		redacted = redacted || ok
+	}
+	if !redacted {
+		return body
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return []byte(redactedValue)
+	}
+	return data
+}
+
+// redactJSON masks the values of v at path and reports whether there were any
+func redactJSON(v interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return redactedValue, true
+	}
+	redacted := false
+	switch v := v.(type) {
+	case []interface{}:
+		if path[0] != "[]" {
+			return v, false
+		}
+		for i, e := range v {
+			var ok bool
+			v[i], ok = redactJSON(e, path[1:])
+			redacted = redacted || ok
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			var ok bool
+			v[k], ok = redactJSON(e, path[1:])
+			redacted = redacted || ok
+		}
+	}
+	return v, redacted
+}
@@ -0,0 +1,180 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type contextKey int
+
+const (
+	redirectStateKey contextKey = iota
+	cacheStatusKey
+	attemptKey
+	rewriteKey
+)
+
+// redirectState records the hops of a single request, it travels in the request context
+// because the underlying http.Client is shared by every RequestBuilder
+type redirectState struct {
+	history []*http.Request
+}
+
+// RedirectPolicy is consulted before following each redirect, after the built-in checks.
+// Returning an error stops following, http.ErrUseLastResponse returns the redirect response as is.
+type RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+// RedirectLoopError is returned when a redirect points back to a request already made,
+// Chain lists the method and URL of every hop, ending with the repeated one
+type RedirectLoopError struct {
+	Chain []string
+}
+
+const defaultMaxRedirect = 10
+
+var (
+	// ErrRedirectLoop matches *RedirectLoopError with errors.Is
+	ErrRedirectLoop = errors.New("Redirect Loop")
+)
+
+// EnableRedirect follows redirects, up to 10 hops unless SetRedirectCount says otherwise
+func (c *HTTPClient) EnableRedirect() *HTTPClient {
+	if c.maxRedirect <= 0 {
+		c.maxRedirect = defaultMaxRedirect
+	}
+	c.redirectEnabled = true
+	return c
+}
+
+// EnableRedirct is the misspelled former name of EnableRedirect.
+//
+// Deprecated: use EnableRedirect instead.
+func (c *HTTPClient) EnableRedirct() *HTTPClient {
+	return c.EnableRedirect()
+}
+
+// DisableRedirects returns redirect responses as is instead of following them
+func (c *HTTPClient) DisableRedirects() *HTTPClient {
+	c.redirectEnabled = false
+	return c
+}
+
+// SetRedirectCount follows redirects up to count hops
+func (c *HTTPClient) SetRedirectCount(count int) *HTTPClient {
+	c.maxRedirect = count
+	c.redirectEnabled = true
+	return c
+}
+
+// SetRedirectPolicy sets a custom check run before following each redirect
+func (c *HTTPClient) SetRedirectPolicy(p RedirectPolicy) *HTTPClient {
+	c.redirectPolicy = p
+	return c
+}
+
+// SetRedirectMethods follows redirects only for requests sent with one of methods,
+// e.g. GET and HEAD so a DELETE is never repeated elsewhere. Redirects of other
+// methods are returned as is. Without methods every method is followed again.
+func (c *HTTPClient) SetRedirectMethods(methods ...string) *HTTPClient {
+	if len(methods) == 0 {
+		c.redirectMethods = nil
+		return c
+	}
+	c.redirectMethods = make(map[string]bool, len(methods))
+	for _, m := range methods {
+		c.redirectMethods[strings.ToUpper(m)] = true
+	}
+	return c
+}
+
+// StripHeadersOnRedirect removes the given headers when a redirect leaves the original host,
+// in addition to Authorization and Cookie which net/http always strips
+func (c *HTTPClient) StripHeadersOnRedirect(keys ...string) *HTTPClient {
+	c.redirectStripHeaders = append(c.redirectStripHeaders, keys...)
+	return c
+}
+
+// RedirectHistory returns the requests which were answered by a redirect, in order.
+// Each request's Response field holds the redirect response.
+func (b *RequestBuilder) RedirectHistory() []*http.Request {
+	if b.redirects == nil {
+		return nil
+	}
+	return b.redirects.history
+}
+
+// checkRedirect is used as http.Client.CheckRedirect.
+// net/http already resolves relative Locations, rewrites POST to GET on 301/302/303
+// as RFC 7231 allows, keeps the method on 307/308 and stores cookies in the jar.
+func (c *HTTPClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if st, ok := req.Context().Value(redirectStateKey).(*redirectState); ok {
+		st.history = append(st.history[:0], via...)
+	}
+
+	if !c.redirectEnabled {
+		return http.ErrUseLastResponse
+	}
+
+	if len(via) > c.maxRedirect {
+		return ErrTooManyRedirection
+	}
+
+	// the original method matters, net/http has already rewritten POST to GET
+	if c.redirectMethods != nil && !c.redirectMethods[via[0].Method] {
+		return http.ErrUseLastResponse
+	}
+
+	if err := redirectLoop(req, via); err != nil {
+		return err
+	}
+
+	if !sameOrigin(req, via[0]) {
+		for _, key := range c.redirectStripHeaders {
+			req.Header.Del(key)
+		}
+		if c.headerPolicy != nil {
+			c.headerPolicy.redirect(req, via[0])
+		}
+	}
+
+	if c.redirectPolicy != nil {
+		return c.redirectPolicy(req, via)
+	}
+
+	return nil
+}
+
+func sameOrigin(a, b *http.Request) bool {
+	return a.URL.Scheme == b.URL.Scheme && a.URL.Host == b.URL.Host
+}
+
+func (e *RedirectLoopError) Error() string {
+	return ErrRedirectLoop.Error() + ": " + strings.Join(e.Chain, " -> ")
+}
+
+// Unwrap returns ErrRedirectLoop
+func (e *RedirectLoopError) Unwrap() error {
+	return ErrRedirectLoop
+}
+
+// redirectLoop fails when req repeats the method and URL of an earlier hop
+func redirectLoop(req *http.Request, via []*http.Request) error {
+	hop := func(r *http.Request) string {
+		return r.Method + " " + r.URL.String()
+	}
+	next := hop(req)
+	for _, r := range via {
+		if hop(r) != next {
+			continue
+		}
+		chain := make([]string, 0, len(via)+1)
+		for _, r := range via {
+			chain = append(chain, hop(r))
+		}
+		return &RedirectLoopError{
+			Chain: append(chain, next),
+		}
+	}
+	return nil
+}
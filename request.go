@@ -0,0 +1,651 @@
+package httgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestBuilder carries the state of a single request and its response.
+// Each builder is independent, so it must not be shared between goroutines.
+type RequestBuilder struct {
+	client               *HTTPClient
+	req                  *http.Request
+	res                  *http.Response
+	header               http.Header
+	cookies              []*http.Cookie
+	body                 io.Reader
+	bodyLength           int64
+	bodyStream           bool
+	method               string
+	url                  string
+	pathParams           map[string]string
+	basic                *BasicAuth
+	digest               *digestAuth
+	errResult            interface{}
+	progress             ProgressFunc
+	extractMaxBytes      int64
+	extractMaxFiles      int
+	onPreconditionFailed UpdateFunc
+	preconditionRetries  int
+	timeout              time.Duration
+	ctx                  context.Context
+	cancel               context.CancelFunc
+	cmu                  sync.Mutex
+	abort                context.CancelFunc
+	canceled             bool
+	held                 bool
+	errs                 []error
+	redirects            *redirectState
+	cacheStatus          string
+	cacheKey             string
+	rewrittenFrom        string
+	variant              string
+	tracer               *tracer
+	checksums            []*checksum
+	headerSums           []*checksum
+	phases               *phaseTrace
+	raw                  *rawHeaderTrace
+	stale                []byte
+	stream               bool
+	statusOnly           bool
+	noBody               bool
+	deleteBody           bool
+	consumedBy           string
+	sending              int32
+	isRequestReady       bool
+	isRequested          bool
+}
+
+func (b *RequestBuilder) SetMethod(method string) *RequestBuilder {
+	b.method = method
+	return b
+}
+
+func (b *RequestBuilder) SetURL(u string) *RequestBuilder {
+	b.url = u
+	return b
+}
+
+func (b *RequestBuilder) SetContentType(ct string) *RequestBuilder {
+	b.unsent("SetContentType")
+	b.header.Del("Content-Type")
+	b.header.Set("Content-Type", ct)
+	return b
+}
+
+func (b *RequestBuilder) SetHeader(key string, value []string) *RequestBuilder {
+	b.unsent("SetHeader")
+	b.header[key] = value
+	return b
+}
+
+func (b *RequestBuilder) SetHeaders(header map[string][]string) *RequestBuilder {
+	b.unsent("SetHeaders")
+	b.header = header
+	return b
+}
+
+func (b *RequestBuilder) AddHeader(key string, value []string) *RequestBuilder {
+	b.unsent("AddHeader")
+	for _, v := range value {
+		b.header.Add(key, v)
+	}
+	return b
+}
+
+func (b *RequestBuilder) AddHeaders(header map[string][]string) *RequestBuilder {
+	b.unsent("AddHeaders")
+	for k, val := range header {
+		for _, v := range val {
+			b.header.Add(k, v)
+		}
+	}
+	return b
+}
+
+func (b *RequestBuilder) SetCookieString(cookie string) *RequestBuilder {
+	b.unsent("SetCookieString")
+	b.header.Set("Cookie", cookie)
+	return b
+}
+
+func (b *RequestBuilder) SetCookie(cookie *http.Cookie) *RequestBuilder {
+	b.unsent("SetCookie")
+	b.cookies = append(b.cookies, cookie)
+	return b
+}
+
+func (b *RequestBuilder) SetCookies(cookies []*http.Cookie) *RequestBuilder {
+	b.unsent("SetCookies")
+	b.cookies = append(b.cookies, cookies...)
+	return b
+}
+
+func (b *RequestBuilder) SetUserAgent(agent string) *RequestBuilder {
+	b.unsent("SetUserAgent")
+	b.header.Set("User-Agent", agent)
+	return b
+}
+
+func (b *RequestBuilder) SetBody(body io.Reader) *RequestBuilder {
+	b.body = body
+	b.bodyStream = false
+	return b
+}
+
+func (b *RequestBuilder) SetBodyString(body string) *RequestBuilder {
+	b.body = strings.NewReader(body)
+	b.bodyStream = false
+	return b
+}
+
+func (b *RequestBuilder) SetBodyByte(body []byte) *RequestBuilder {
+	b.body = bytes.NewReader(body)
+	b.bodyStream = false
+	return b
+}
+
+func (b *RequestBuilder) SetRequest(req *http.Request) *RequestBuilder {
+	b.req = req
+	b.isRequestReady = true
+	return b
+}
+
+func (b *RequestBuilder) SetBasicAuth(user, pass string) *RequestBuilder {
+	b.unsent("SetBasicAuth")
+	b.basic = &BasicAuth{
+		User: user,
+		Pass: pass,
+	}
+	return b
+}
+
+func (b *RequestBuilder) SetAuth(token string) *RequestBuilder {
+	b.SetHeader("Authorization", []string{token})
+	return b
+}
+
+func (b *RequestBuilder) newRequest() *RequestBuilder {
+	if b.isRequestReady {
+		return b
+	}
+
+	u := b.expandURL()
+	if ru := b.client.rewriteURL(u); ru != u {
+		b.rewrittenFrom = u
+		u = ru
+	}
+	if b.client.split != nil {
+		u, b.variant = b.client.split.route(u)
+	}
+
+	parsedURL, err := checkURL(u)
+
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	b.url = parsedURL.String()
+
+	err = b.emptyBody()
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	if !b.checkBody() {
+		return b
+	}
+
+	err = b.compressBody(parsedURL.Host)
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	b.req, err = http.NewRequest(b.method, b.url, b.body)
+
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	if b.bodyStream {
+		b.streamBody()
+	}
+
+	b.req.Header = b.header
+
+	for k, v := range b.client.header {
+		if _, ok := b.req.Header[k]; !ok {
+			b.req.Header[k] = append([]string(nil), v...)
+		}
+	}
+	b.expectContinue()
+
+	if b.req.Header.Get("Accept-Encoding") == "" && b.client.acceptEncoding != "" {
+		b.req.Header.Set("Accept-Encoding", b.client.acceptEncoding)
+	}
+
+	if b.req.Header.Get("User-Agent") == "" && b.client.userAgent != "" {
+		b.req.Header.Set("User-Agent", b.client.userAgent)
+	}
+
+	for _, cookie := range b.cookies {
+		b.req.AddCookie(cookie)
+	}
+
+	if b.basic != nil {
+		b.req.SetBasicAuth(b.basic.User, b.basic.Pass)
+	}
+
+	err = b.setIdempotencyKey()
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	err = validateHeader(b.req)
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	b.isRequestReady = true
+
+	return b
+}
+
+func (b *RequestBuilder) Do() *RequestBuilder {
+	if b.isRequested && !b.isRequestReady {
+		// failed to build, sending again only repeats the errors
+		return b
+	}
+	end, ok := b.checkSend()
+	if !ok {
+		return b
+	}
+	defer end()
+	return b.newRequest().do()
+}
+
+// DoWithContext sends the request with ctx, see WithContext
+func (b *RequestBuilder) DoWithContext(ctx context.Context) *RequestBuilder {
+	return b.WithContext(ctx).Do()
+}
+
+func (b *RequestBuilder) do() *RequestBuilder {
+	c := b.client
+
+	b.isRequested = true
+
+	if !b.isRequestReady {
+		return b
+	}
+
+	ctx, cancel := b.chainContext()
+	b.req = b.req.WithContext(ctx)
+	b.cancel = cancel
+
+	// credentials are part of the cache key, so they are set before it is computed
+	err := b.applyToken()
+	if err != nil {
+		b.cancel()
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	err = b.applyHeaderProviders()
+	if err != nil {
+		b.cancel()
+		b.fail(ErrPhaseBuild, err)
+		return b
+	}
+
+	labels := requestLabels(b.req)
+	labels.Variant = b.variant
+	labels.Route = c.routeOf(b.req)
+
+	var flight *cacheFlight
+	cacheable := c.cacheEnabled && !b.stream && cacheableRequest(b.req)
+	if cacheable {
+		// the key is computed once, later changes of the request headers must not move the entry
+		key := c.cacheKey(b.req)
+		b.cacheKey = key
+		data, ok := c.cache.Get(key)
+
+		if ok && c.expiry.isStale(key) {
+			if c.expiry.backingOff(key) && b.serveCached(data, CacheStatusStale, labels) {
+				atomic.AddUint64(&c.stats.cacheHits, 1)
+				return b
+			}
+			b.revalidate(data)
+			ok = false
+		}
+
+		if !ok && coalescable(b.req) {
+			f, leader := c.flights.join(key)
+			if leader {
+				flight = f
+				defer c.flights.release(key, f)
+			} else {
+				data, ok = f.wait(b.req.Context())
+				// a failed revalidation of the leader leaves the stale copy to its waiters as well
+				if !ok && b.stale != nil && c.expiry.backingOff(key) && b.serveCached(b.stale, CacheStatusStale, labels) {
+					atomic.AddUint64(&c.stats.cacheHits, 1)
+					return b
+				}
+			}
+		}
+
+		if ok && b.serveCached(data, CacheStatusHit, labels) {
+			atomic.AddUint64(&c.stats.cacheHits, 1)
+			return b
+		}
+
+		atomic.AddUint64(&c.stats.cacheMisses, 1)
+		if c.metrics != nil {
+			c.metrics.CacheMiss(labels)
+		}
+		b.cacheStatus = CacheStatusMiss
+		b.req = b.req.WithContext(context.WithValue(b.req.Context(), cacheStatusKey, CacheStatusMiss))
+	}
+
+	b.redirects = new(redirectState)
+	b.req = b.req.WithContext(context.WithValue(b.req.Context(), redirectStateKey, b.redirects))
+
+	if b.rewrittenFrom != "" {
+		b.req = b.req.WithContext(context.WithValue(b.req.Context(), rewriteKey, b.rewrittenFrom))
+	}
+
+	b.phases = newPhaseTrace()
+	b.req = b.req.WithContext(httptrace.WithClientTrace(b.req.Context(), b.phases.clientTrace()))
+	b.req = b.req.WithContext(httptrace.WithClientTrace(b.req.Context(), connTrace()))
+
+	if c.rawHeaders {
+		b.raw = new(rawHeaderTrace)
+		b.req = b.req.WithContext(httptrace.WithClientTrace(b.req.Context(), b.raw.clientTrace()))
+	}
+
+	if b.tracer == nil && c.traceEnabled {
+		b.tracer = newTracer()
+	}
+
+	if b.tracer != nil {
+		b.tracer.start = time.Now()
+		b.req = b.req.WithContext(httptrace.WithClientTrace(b.req.Context(), b.tracer.clientTrace()))
+	}
+
+	if b.timeout > 0 {
+		tctx, tcancel := context.WithTimeout(b.req.Context(), b.timeout)
+		b.req = b.req.WithContext(tctx)
+		b.cancel = func() {
+			tcancel()
+			cancel()
+		}
+	}
+
+	var res *http.Response
+
+	start := time.Now()
+	if c.metrics != nil {
+		c.metrics.IncInFlight(labels)
+	}
+
+	done := c.stats.begin(labels.Host)
+	res, err = c.client.Do(b.req)
+	done()
+
+	if c.metrics != nil {
+		c.metrics.DecInFlight(labels)
+		if res != nil {
+			labels.StatusCode = res.StatusCode
+		}
+		c.metrics.ObserveRequest(labels, time.Since(start))
+	}
+
+	if b.stale != nil && b.settleRevalidation(res, err, labels) {
+		return b
+	}
+
+	if err != nil {
+		b.cancel()
+		b.fail(transportPhase(err), err)
+		return b
+	}
+
+	if res.StatusCode == http.StatusUnauthorized && b.digest != nil {
+		res, err = b.retryDigest(res)
+		if err != nil {
+			b.cancel()
+			b.fail(transportPhase(err), err)
+			return b
+		}
+	}
+
+	if res.StatusCode == http.StatusPreconditionFailed && b.onPreconditionFailed != nil {
+		res, err = b.retryPrecondition(res)
+		if err != nil {
+			b.cancel()
+			b.fail(transportPhase(err), err)
+			return b
+		}
+	}
+
+	if b.raw != nil {
+		b.raw.restoreTLS(res)
+	}
+
+	// event streams reconnect after closing the body, EventStream releases the chain itself
+	if !b.stream {
+		res.Body = &cancelBody{
+			ReadCloser: res.Body,
+			cancel:     b.releaseChain,
+		}
+		res.Body = c.stats.body(res.Body)
+	}
+
+	b.headerSums = headerChecksums(b.req, res)
+	res.Body = verifyBody(res.Body, b.headerSums)
+
+	if c.bodyReadTimeout > 0 {
+		res.Body = newIdleTimeoutBody(res.Body, c.bodyReadTimeout)
+	}
+
+	if enc := res.Header.Get("Content-Encoding"); enc != "" && hasBody(b.req, res) {
+		var body io.ReadCloser
+		body, err = decodeContent(res.Body, enc, c.hostDecoders(responseHost(b.req, res)))
+		if err != nil {
+			b.res = res
+			b.fail(ErrPhaseBody, err)
+			return b
+		}
+		res.Body = body
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Uncompressed = true
+	}
+
+	if c.maxResponseBodySize > 0 {
+		if res.ContentLength > c.maxResponseBodySize {
+			res.Body.Close()
+			b.fail(ErrPhaseResponse, ErrResponseTooLarge)
+			return b
+		}
+		res.Body = &limitBody{
+			ReadCloser: res.Body,
+			remaining:  c.maxResponseBodySize,
+		}
+	}
+
+	res.Body = verifyBody(res.Body, b.checksums)
+
+	if c.drift != nil {
+		c.drift.watch(b.req, res, labels.Route)
+	}
+
+	if c.metrics != nil {
+		res.Body = &metricsBody{
+			ReadCloser: res.Body,
+			collector:  c.metrics,
+			labels:     labels,
+		}
+	}
+
+	if b.tracer != nil {
+		res.Body = &traceBody{
+			ReadCloser: res.Body,
+			tracer:     b.tracer,
+		}
+	}
+
+	b.res = res
+
+	b.checkHTTPError(res)
+
+	if c.cacheStatusHeader && b.cacheStatus != "" {
+		res.Header.Set(HeaderXCache, b.cacheStatus)
+	}
+
+	if cacheable && !b.statusOnly && cacheableResponse(res) {
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			b.fail(ErrPhaseBody, err)
+			return b
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(data))
+		res.ContentLength = int64(len(data))
+
+		dump, err := encodeResponse(res)
+		if err != nil {
+			b.fail(ErrPhaseBody, err)
+			return b
+		}
+
+		// the chain context is released with the body, so only the caller's context is checked
+		parent := b.ctx
+		if parent == nil {
+			parent = context.Background()
+		}
+		key := b.cacheKey
+		if flight != nil {
+			// the flight ends once stored, waiters and later callers share the response meanwhile
+			flight.publish(dump)
+		}
+		go func(ctx context.Context, store CacheStore, key string, flight *cacheFlight) {
+			if flight != nil {
+				defer c.flights.remove(key, flight)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if store.Set(key, dump) == nil {
+				c.expiry.schedule(store, key)
+			}
+		}(parent, c.cache, key, flight)
+	}
+
+	return b
+}
+
+func (b *RequestBuilder) JSON(d interface{}) *RequestBuilder {
+	if !b.isRequested {
+		b.Do()
+	}
+	if b.res == nil {
+		return b
+	}
+	if !b.consume("JSON", false) {
+		return b
+	}
+	err := b.client.decodeJSON(b.res.Body, d)
+	if err != nil {
+		b.fail(ErrPhaseDecode, err)
+	}
+	return b
+}
+
+func (b *RequestBuilder) XML(d interface{}) *RequestBuilder {
+	if !b.isRequested {
+		b.Do()
+	}
+	if b.res == nil {
+		return b
+	}
+	if !b.consume("XML", false) {
+		return b
+	}
+	err := b.client.decodeXML(b.res.Body, d)
+	if err != nil {
+		b.fail(ErrPhaseDecode, err)
+	}
+	return b
+}
+
+func (b *RequestBuilder) GetByteBody() ([]byte, []error) {
+	if !b.isRequested {
+		b.Do()
+	}
+	if b.res == nil {
+		return nil, b.errs
+	}
+	if !b.consume("GetByteBody", false) {
+		return nil, b.errs
+	}
+	data, err := ioutil.ReadAll(b.res.Body)
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return data, b.errs
+}
+
+func (b *RequestBuilder) GetRawBody() (io.ReadCloser, []error) {
+	if !b.isRequested {
+		b.Do()
+	}
+	if b.res == nil {
+		return nil, b.errs
+	}
+	if !b.consume("GetRawBody", false) {
+		return nil, b.errs
+	}
+	return b.res.Body, b.errs
+}
+
+func (b *RequestBuilder) GetRequest() (*http.Request, []error) {
+	return b.newRequest().req, b.errs
+}
+
+func (b *RequestBuilder) GetResponse() (*http.Response, []error) {
+	if !b.isRequested {
+		b.Do()
+	}
+	return b.res, b.errs
+}
+
+func (b *RequestBuilder) GetErrors() []error {
+	return b.errs
+}
+
+func (b *RequestBuilder) Close() []error {
+	if b.res == nil {
+		return b.errs
+	}
+	io.Copy(ioutil.Discard, b.res.Body)
+	err := b.res.Body.Close()
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return b.errs
+}
@@ -0,0 +1,102 @@
+package httgo
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+func (b *RequestBuilder) response() *http.Response {
+	if !b.isRequested {
+		b.Do()
+	}
+	return b.res
+}
+
+// StatusCode returns the response status code, or 0 when no response was received
+func (b *RequestBuilder) StatusCode() int {
+	res := b.response()
+	if res == nil {
+		return 0
+	}
+	return res.StatusCode
+}
+
+// StatusOnly sends the request and returns the response status code, or 0 when no response was received,
+// without reading the body: up to 64KB are discarded so the connection can be reused, larger bodies are
+// closed unread. Responses are not stored in the cache, Status and Header remain available afterwards.
+func (b *RequestBuilder) StatusOnly() (int, []error) {
+	if !b.isRequested {
+		b.statusOnly = true
+		b.Do()
+	}
+	if b.res == nil {
+		return 0, b.errs
+	}
+	if !b.consume("StatusOnly", false) {
+		return b.res.StatusCode, b.errs
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(b.res.Body, 1<<16))
+	if err := b.res.Body.Close(); err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	return b.res.StatusCode, b.errs
+}
+
+// Status returns the response status line such as "200 OK"
+func (b *RequestBuilder) Status() string {
+	res := b.response()
+	if res == nil {
+		return ""
+	}
+	return res.Status
+}
+
+// Header returns the response header
+func (b *RequestBuilder) Header() http.Header {
+	res := b.response()
+	if res == nil {
+		return nil
+	}
+	return res.Header
+}
+
+// IsSuccess reports whether the response status is 2xx
+func (b *RequestBuilder) IsSuccess() bool {
+	return b.StatusCode()/100 == 2
+}
+
+// IsRedirect reports whether the response status is 3xx
+func (b *RequestBuilder) IsRedirect() bool {
+	return b.StatusCode()/100 == 3
+}
+
+// IsClientError reports whether the response status is 4xx
+func (b *RequestBuilder) IsClientError() bool {
+	return b.StatusCode()/100 == 4
+}
+
+// IsServerError reports whether the response status is 5xx
+func (b *RequestBuilder) IsServerError() bool {
+	return b.StatusCode()/100 == 5
+}
+
+// String reads the whole response body as a string.
+// The body is kept in memory so it can still be decoded afterwards.
+func (b *RequestBuilder) String() (string, []error) {
+	res := b.response()
+	if res == nil {
+		return "", b.errs
+	}
+	if !b.consume("String", true) {
+		return "", b.errs
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		b.fail(ErrPhaseBody, err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return string(data), b.errs
+}
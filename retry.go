@@ -0,0 +1,132 @@
+package httgo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HeaderIdempotencyKey is the header set by SetIdempotencyKey and EnableIdempotency
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// SetRetry retries attempts failing with a transport error, 429 or 5xx up to retries times,
+// waiting backoff (500ms by default), doubled after each retry, or the Retry-After delay.
+// Only idempotent methods and requests with an Idempotency-Key are retried,
+// and only when their body can be sent again.
+func (c *HTTPClient) SetRetry(retries int, backoff time.Duration) *HTTPClient {
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	c.retries = retries
+	c.retryBackoff = backoff
+	return c
+}
+
+// EnableIdempotency sets a random Idempotency-Key on every POST and PATCH request
+// which has none, so they are retried by SetRetry without risking duplicates
+func (c *HTTPClient) EnableIdempotency() *HTTPClient {
+	c.idempotency = true
+	return c
+}
+
+// SetIdempotencyKey sets the Idempotency-Key header, which makes SetRetry
+// retry non-idempotent methods such as POST. The key is kept across retries.
+func (b *RequestBuilder) SetIdempotencyKey(key string) *RequestBuilder {
+	b.unsent("SetIdempotencyKey")
+	b.header.Set(HeaderIdempotencyKey, key)
+	return b
+}
+
+// setIdempotencyKey generates the key of a non-idempotent request when EnableIdempotency is set
+func (b *RequestBuilder) setIdempotencyKey() error {
+	if !b.client.idempotency || b.header.Get(HeaderIdempotencyKey) != "" {
+		return nil
+	}
+	switch b.req.Method {
+	case http.MethodPost, http.MethodPatch:
+	default:
+		return nil
+	}
+	key, err := newUUID()
+	if err != nil {
+		return err
+	}
+	b.header.Set(HeaderIdempotencyKey, key)
+	return nil
+}
+
+// retryRoundTrip sends req with next, retrying failed attempts as configured by SetRetry
+func (c *HTTPClient) retryRoundTrip(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if c.retries <= 0 || !replayable(req) {
+		return next(req)
+	}
+
+	ctx := req.Context()
+	backoff := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		areq := req
+		if attempt > 1 {
+			atomic.AddUint64(&c.stats.retries, 1)
+			areq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				areq.Body = body
+			}
+		}
+		areq = areq.WithContext(WithAttempt(ctx, attempt, c.retries+1))
+
+		res, err := next(areq)
+		if attempt > c.retries || ctx.Err() != nil || !retryableAttempt(res, err) {
+			return res, err
+		}
+
+		delay := backoff
+		if res != nil {
+			if d, ok := retryAfter(res.Header); ok {
+				delay = d
+			}
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+			res.Body.Close()
+		}
+		backoff *= 2
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// retryableAttempt reports whether an attempt failed in a way a retry may fix
+func retryableAttempt(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen) &&
+			!errors.Is(err, ErrBlockedDestination) &&
+			!errors.Is(err, ErrInvalidHeader)
+	}
+	return retryableStatus(res.StatusCode)
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID
+func newUUID() (string, error) {
+	u := make([]byte, 16)
+	_, err := io.ReadFull(rand.Reader, u)
+	if err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	h := hex.EncodeToString(u)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
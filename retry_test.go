@@ -0,0 +1,68 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAttemptsPassMiddlewares(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var seen []Attempt
+	c := New().SetRetry(3, time.Millisecond).Use(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			a, _ := AttemptFromContext(req.Context())
+			seen = append(seen, a)
+			return next.RoundTrip(req)
+		})
+	})
+
+	code, errs := c.Get(srv.URL).StatusOnly()
+	if len(errs) != 0 || code != http.StatusOK {
+		t.Fatalf("got %d %v, want 200", code, errs)
+	}
+	want := []Attempt{{Number: 1, Max: 4}, {Number: 2, Max: 4}, {Number: 3, Max: 4}}
+	if !reflect.DeepEqual(seen, want) {
+		t.Fatalf("middleware saw %v, want %v", seen, want)
+	}
+}
+
+func TestRetrySkipsBlockedDestination(t *testing.T) {
+	var calls int
+	c := New().SetRetry(3, time.Millisecond).Use(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return next.RoundTrip(req)
+		})
+	}).EnableSSRFProtection()
+
+	errs := c.Get("http://127.0.0.1/").Do().Close()
+	if len(errs) == 0 || !errors.Is(errs[0], ErrBlockedDestination) {
+		t.Fatalf("want ErrBlockedDestination, got %v", errs)
+	}
+	if calls != 1 {
+		t.Fatalf("middleware called %d times, want 1", calls)
+	}
+}
+
+func TestSetIdempotencyKeyAfterSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	b := New().EnableStrictMode().Post(srv.URL).Do()
+	b.SetIdempotencyKey("key")
+	if errs := b.Close(); len(errs) == 0 || !errors.Is(errs[len(errs)-1], ErrMisuse) {
+		t.Fatalf("want ErrMisuse, got %v", errs)
+	}
+}
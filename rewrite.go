@@ -0,0 +1,46 @@
+package httgo
+
+import (
+	"context"
+	"regexp"
+)
+
+// rewriteRule rewrites request URLs matching pattern, see AddRewriteRule
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// AddRewriteRule rewrites request URLs matching the regular expression pattern before they are sent,
+// e.g. to route some paths to a new host or version prefix during a migration. replacement may refer
+// to submatches as in regexp.Expand, e.g. AddRewriteRule(`^https://api\.example\.com/v1/(users/.*)`,
+// "https://users.example.com/v2/$1"). Rules see the URL with the base URL and path params applied,
+// they are tried in the order added and the first match wins. Redirect locations are not rewritten.
+// GetRequest, traces and spans show the rewritten URL, the original one is TraceInfo.RewrittenFrom.
+func (c *HTTPClient) AddRewriteRule(pattern, replacement string) *HTTPClient {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+		return c
+	}
+	c.rewriteRules = append(c.rewriteRules, &rewriteRule{
+		pattern:     re,
+		replacement: replacement,
+	})
+	return c
+}
+
+// rewriteURL applies the first rule matching u
+func (c *HTTPClient) rewriteURL(u string) string {
+	for _, r := range c.rewriteRules {
+		if r.pattern.MatchString(u) {
+			return r.pattern.ReplaceAllString(u, r.replacement)
+		}
+	}
+	return u
+}
+
+func rewrittenFromContext(ctx context.Context) string {
+	from, _ := ctx.Value(rewriteKey).(string)
+	return from
+}
@@ -0,0 +1,188 @@
+package httgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxDriftBody is the largest response body the schema drift detector inspects
+const maxDriftBody = 1 << 20
+
+// SchemaDrift describes a change in the JSON shape of the responses of an endpoint, see EnableSchemaDrift.
+// Fields are paths such as "items[].id", with "[]" standing for the elements of an array.
+type SchemaDrift struct {
+	// Endpoint is the method, host and route of the request, see SetURLTemplateFunc,
+	// or its path with IDs replaced as by CollapseIDs
+	Endpoint string
+	Added    []string
+	Removed  []string
+	// Changed lists fields whose type changed, e.g. "id: number -> string"
+	Changed []string
+}
+
+// schemaDrift remembers the last JSON shape of each endpoint
+type schemaDrift struct {
+	fn     func(SchemaDrift)
+	mu     sync.Mutex
+	shapes map[string]map[string]string
+}
+
+type driftBody struct {
+	io.ReadCloser
+	drift    *schemaDrift
+	endpoint string
+	buf      bytes.Buffer
+	done     bool
+}
+
+// EnableSchemaDrift records the JSON shape (field paths and types) of successful JSON responses
+// per endpoint and calls fn whenever it differs from the shape seen last, e.g. to catch upstream
+// contract changes after a deployment. The first response of an endpoint only records its shape.
+// Bodies are inspected once read to the end or closed, those larger than 1MB are skipped.
+// Fields missing because they are optional are reported as removed as well.
+func (c *HTTPClient) EnableSchemaDrift(fn func(SchemaDrift)) *HTTPClient {
+	c.drift = &schemaDrift{
+		fn:     fn,
+		shapes: make(map[string]map[string]string),
+	}
+	return c
+}
+
+// watch wraps the body of JSON responses to record their shape once fully read
+func (d *schemaDrift) watch(req *http.Request, res *http.Response, route string) {
+	if res.StatusCode < 200 || res.StatusCode > 299 || res.ContentLength > maxDriftBody || !hasBody(req, res) {
+		return
+	}
+	mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || (mt != "application/json" && !strings.HasSuffix(mt, "+json")) {
+		return
+	}
+	if route == "" {
+		route = CollapseIDs(req)
+	}
+	endpoint := req.Method + " " + req.URL.Host + route
+	res.Body = &driftBody{
+		ReadCloser: res.Body,
+		drift:      d,
+		endpoint:   endpoint,
+	}
+}
+
+func (b *driftBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.done {
+		if b.buf.Len()+n > maxDriftBody {
+			b.done = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close records the shape as well, decoders stop reading once the JSON value is complete
+// and partial bodies do not parse
+func (b *driftBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *driftBody) finish() {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.drift.observe(b.endpoint, b.buf.Bytes())
+	b.buf = bytes.Buffer{}
+}
+
+// observe compares the shape of data with the last one of endpoint
+func (d *schemaDrift) observe(endpoint string, data []byte) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return
+	}
+	shape := make(map[string]string)
+	jsonShape(shape, "", v)
+
+	d.mu.Lock()
+	prev, ok := d.shapes[endpoint]
+	d.shapes[endpoint] = shape
+	d.mu.Unlock()
+	if !ok || d.fn == nil {
+		return
+	}
+
+	drift := SchemaDrift{Endpoint: endpoint}
+	for path, typ := range shape {
+		old, ok := prev[path]
+		switch {
+		case !ok:
+			drift.Added = append(drift.Added, path)
+		case old != typ && old != "null" && typ != "null":
+			drift.Changed = append(drift.Changed, path+": "+old+" -> "+typ)
+		}
+	}
+	for path := range prev {
+		if _, ok := shape[path]; !ok {
+			drift.Removed = append(drift.Removed, path)
+		}
+	}
+	if len(drift.Added)+len(drift.Removed)+len(drift.Changed) == 0 {
+		return
+	}
+	sort.Strings(drift.Added)
+	sort.Strings(drift.Removed)
+	sort.Strings(drift.Changed)
+	d.fn(drift)
+}
+
+// jsonShape records the type of v and of everything below it under path,
+// the elements of an array share one path
+func jsonShape(shape map[string]string, path string, v interface{}) {
+	var typ string
+	switch v := v.(type) {
+	case map[string]interface{}:
+		typ = "object"
+		for k, e := range v {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			jsonShape(shape, p, e)
+		}
+	case []interface{}:
+		typ = "array"
+		for _, e := range v {
+			jsonShape(shape, path+"[]", e)
+		}
+	case string:
+		typ = "string"
+	case float64:
+		typ = "number"
+	case bool:
+		typ = "bool"
+	default:
+		typ = "null"
+	}
+	if path == "" {
+		return
+	}
+	if old, ok := shape[path]; ok && old != "null" && typ != old {
+		if typ == "null" {
+			return
+		}
+		typ = "mixed"
+	}
+	shape[path] = typ
+}
@@ -0,0 +1,40 @@
+package httgo
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResetTransport swaps in a fresh transport with the same configuration and closes the idle
+// connections of the old one, e.g. from CircuitBreakerConfig.OnOpen when sockets look corrupted.
+// In-flight requests complete on the old transport.
+func (c *HTTPClient) ResetTransport() *HTTPClient {
+	c.transportMu.Lock()
+	old := c.transport
+	c.transport = old.Clone()
+	c.transportMu.Unlock()
+
+	old.CloseIdleConnections()
+	return c
+}
+
+// EnableKeepAliveProbe validates pooled connections with TCP keep-alive probes: after idle
+// without traffic, count probes are sent interval apart and a connection which does not
+// answer is dropped from the pool instead of failing the next request reusing it
+func (c *HTTPClient) EnableKeepAliveProbe(idle, interval time.Duration, count int) *HTTPClient {
+	c.dialer.KeepAliveConfig = net.KeepAliveConfig{
+		Enable:   true,
+		Idle:     idle,
+		Interval: interval,
+		Count:    count,
+	}
+	return c
+}
+
+func (c *HTTPClient) currentTransport() *http.Transport {
+	c.transportMu.RLock()
+	t := c.transport
+	c.transportMu.RUnlock()
+	return t
+}
@@ -0,0 +1,236 @@
+package httgo
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Signer signs each outgoing attempt, including redirect hops and retries,
+// right before it is sent. Sign receives a copy of the request it may modify.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// AWSCredentials are the credentials used by the AWS Signature Version 4 signer
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+type awsV4Signer struct {
+	region  string
+	service string
+	creds   AWSCredentials
+	now     func() time.Time
+}
+
+const (
+	awsV4Algorithm     = "AWS4-HMAC-SHA256"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+	awsV4TimeFormat    = "20060102T150405Z"
+	awsV4DateFormat    = "20060102"
+)
+
+// ErrUnsignedPayload is returned when signing a body which cannot be replayed for a service other than S3,
+// only S3 accepts the UNSIGNED-PAYLOAD hash
+var ErrUnsignedPayload = errors.New("Unsigned Payload Not Supported")
+
+// SetSigner signs every attempt with s
+func (c *HTTPClient) SetSigner(s Signer) *HTTPClient {
+	c.signer = s
+	return c
+}
+
+// SetSignerAWSv4 signs every attempt with AWS Signature Version 4 for region and service.
+// Bodies which cannot be replayed are sent with an UNSIGNED-PAYLOAD hash to S3
+// and fail with ErrUnsignedPayload for other services.
+func (c *HTTPClient) SetSignerAWSv4(region, service string, creds AWSCredentials) *HTTPClient {
+	return c.SetSigner(&awsV4Signer{
+		region:  region,
+		service: service,
+		creds:   creds,
+		now:     time.Now,
+	})
+}
+
+func (c *HTTPClient) sign(req *http.Request) (*http.Request, error) {
+	req = req.Clone(req.Context())
+	err := c.signer.Sign(req)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *awsV4Signer) Sign(req *http.Request) error {
+	t := s.now().UTC()
+	amzDate := t.Format(awsV4TimeFormat)
+	date := t.Format(awsV4DateFormat)
+
+	payloadHash, err := payloadSHA256(req)
+	if err != nil {
+		return err
+	}
+	if payloadHash == awsUnsignedPayload && s.service != "s3" {
+		return ErrUnsignedPayload
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if s.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{
+		"host": host,
+	}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			vals := make([]string, len(v))
+			for i := range v {
+				vals[i] = strings.Join(strings.Fields(v[i]), " ")
+			}
+			headers[lk] = strings.Join(vals, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders bytes.Buffer
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + s.service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		awsV4Algorithm,
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.creds.SecretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", awsV4Algorithm+
+		" Credential="+s.creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+// canonicalPath encodes every path segment, twice for every service but S3
+func (s *awsV4Signer) canonicalPath(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		seg = awsURIEncode(seg)
+		if s.service != "s3" {
+			seg = awsURIEncode(seg)
+		}
+		segs[i] = seg
+	}
+	return strings.Join(segs, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			pairs = append(pairs, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes everything but the RFC 3986 unreserved characters
+func awsURIEncode(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			buf.WriteByte(c)
+			continue
+		}
+		buf.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return buf.String()
+}
+
+func payloadSHA256(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return hexSHA256(nil), nil
+	}
+	if req.GetBody == nil {
+		return awsUnsignedPayload, nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, rc)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	io.WriteString(h, data)
+	return h.Sum(nil)
+}
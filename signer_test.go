@@ -0,0 +1,41 @@
+package httgo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSignerAWSv4UnsignedPayload(t *testing.T) {
+	var hash string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hash = r.Header.Get("X-Amz-Content-Sha256")
+	}))
+	defer srv.Close()
+
+	creds := AWSCredentials{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	}
+
+	errs := New().SetSignerAWSv4("us-east-1", "s3", creds).Put(srv.URL+"/bucket/key").
+		SetBodyStream(io.LimitReader(strings.NewReader("data"), 4), 4).Do().Close()
+	if len(errs) != 0 || hash != awsUnsignedPayload {
+		t.Fatalf("s3: errors %v, payload hash %q", errs, hash)
+	}
+
+	errs = New().SetSignerAWSv4("us-east-1", "execute-api", creds).Put(srv.URL+"/api").
+		SetBodyStream(io.LimitReader(strings.NewReader("data"), 4), 4).Do().Close()
+	if len(errs) == 0 || !errors.Is(errs[0], ErrUnsignedPayload) {
+		t.Fatalf("execute-api: want ErrUnsignedPayload, got %v", errs)
+	}
+
+	errs = New().SetSignerAWSv4("us-east-1", "execute-api", creds).Put(srv.URL + "/api").
+		SetBodyString("data").Do().Close()
+	if len(errs) != 0 {
+		t.Fatalf("execute-api with a replayable body: %v", errs)
+	}
+}
@@ -0,0 +1,76 @@
+package httgo
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// VariantPrimary and VariantCanary are the MetricsLabels.Variant of requests split by SplitTraffic
+	VariantPrimary = "primary"
+	VariantCanary  = "canary"
+)
+
+// trafficSplit sends percent of the requests to the primary base URL to canary instead
+type trafficSplit struct {
+	primary string
+	canary  string
+	percent float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// SplitTraffic routes percent (0 to 100) of the requests to the primary base URL to the canary base URL,
+// keeping the rest of the path and query, e.g. SplitTraffic("https://api.example.com/v1",
+// "https://canary.example.com/v1", 5). Split requests carry MetricsLabels.Variant VariantPrimary or
+// VariantCanary so both sides of a rollout can be compared. primary becomes the base URL when none is set.
+// The split is applied after AddRewriteRule, a percent of 0 sends everything to primary.
+func (c *HTTPClient) SplitTraffic(primary, canary string, percent float64) *HTTPClient {
+	p, err := checkURL(strings.TrimSuffix(primary, "/"))
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+		return c
+	}
+	cn, err := checkURL(strings.TrimSuffix(canary, "/"))
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+		return c
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	c.split = &trafficSplit{
+		primary: p.String(),
+		canary:  cn.String(),
+		percent: percent,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if c.baseURL == "" {
+		c.baseURL = c.split.primary
+	}
+	return c
+}
+
+// route returns the URL u is sent to and its variant, u as is and "" for URLs outside primary
+func (s *trafficSplit) route(u string) (string, string) {
+	if !strings.HasPrefix(u, s.primary) {
+		return u, ""
+	}
+	rest := u[len(s.primary):]
+	if rest != "" && !strings.ContainsRune("/?#", rune(rest[0])) {
+		return u, ""
+	}
+
+	s.mu.Lock()
+	n := s.rand.Float64() * 100
+	s.mu.Unlock()
+	if n >= s.percent {
+		return u, VariantPrimary
+	}
+	return s.canary + rest, VariantCanary
+}
@@ -0,0 +1,170 @@
+package httgo
+
+import (
+	"bufio"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single Server-Sent Event
+type Event struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// EventHandler receives each event; returning an error stops the stream
+type EventHandler func(Event) error
+
+const defaultReconnectDelay = 3 * time.Second
+
+var (
+	// ErrNotEventStream is returned when the response is not text/event-stream
+	ErrNotEventStream = errors.New("Response is not an Event Stream")
+)
+
+// EventStream consumes the response as Server-Sent Events, calling fn for each event.
+// When the connection drops it reconnects with Last-Event-ID until the request context
+// is done, the server answers 204 or fn returns an error.
+func (b *RequestBuilder) EventStream(fn EventHandler) []error {
+	b.stream = true
+	if !b.isRequested {
+		if b.header.Get("Accept") == "" {
+			b.header.Set("Accept", "text/event-stream")
+		}
+		b.header.Set("Cache-Control", "no-cache")
+	}
+
+	res := b.response()
+	if res == nil {
+		return b.errs
+	}
+	if b.cancel != nil {
+		defer b.cancel()
+	}
+
+	var lastID string
+	delay := defaultReconnectDelay
+
+	for {
+		if res.StatusCode == http.StatusNoContent {
+			res.Body.Close()
+			return b.errs
+		}
+		if res.StatusCode != http.StatusOK || !isEventStream(res.Header.Get("Content-Type")) {
+			res.Body.Close()
+			b.fail(ErrPhaseResponse, ErrNotEventStream)
+			return b.errs
+		}
+
+		err := readEvents(res, &lastID, &delay, fn)
+		res.Body.Close()
+		if err != nil {
+			b.fail(ErrPhaseBody, err)
+			return b.errs
+		}
+
+		ctx := b.req.Context()
+		select {
+		case <-ctx.Done():
+			b.fail(ErrPhaseSend, ctx.Err())
+			return b.errs
+		case <-time.After(delay):
+		}
+
+		req := b.req.Clone(ctx)
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+
+		res, err = b.client.client.Do(req)
+		if err != nil {
+			b.fail(transportPhase(err), err)
+			return b.errs
+		}
+	}
+}
+
+// EventStreamChan is EventStream delivering events over a channel.
+// The channel is closed when the stream ends, errors are then available from GetErrors.
+func (b *RequestBuilder) EventStreamChan() <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		b.EventStream(func(e Event) error {
+			ch <- e
+			return nil
+		})
+	}()
+	return ch
+}
+
+func readEvents(res *http.Response, lastID *string, delay *time.Duration, fn EventHandler) error {
+	var (
+		e    Event
+		data []string
+	)
+
+	sc := bufio.NewScanner(res.Body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for sc.Scan() {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+
+		if line == "" {
+			if len(data) == 0 {
+				e.Type = ""
+				continue
+			}
+			e.ID = *lastID
+			e.Data = strings.Join(data, "\n")
+			if e.Type == "" {
+				e.Type = "message"
+			}
+			err := fn(e)
+			if err != nil {
+				return err
+			}
+			e = Event{}
+			data = data[:0]
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			e.Type = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				*lastID = value
+			}
+		case "retry":
+			ms, err := strconv.Atoi(value)
+			if err == nil && ms >= 0 {
+				*delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	// a dropped connection is handled by reconnecting
+	return nil
+}
+
+func isEventStream(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	return err == nil && mt == "text/event-stream"
+}
@@ -0,0 +1,48 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnableSSRFProtection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"loopback literal", srv.URL},
+		{"loopback resolved at dial", strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)},
+		{"metadata host", "http://metadata.google.internal/computeMetadata/v1/"},
+		{"link-local metadata address", "http://169.254.169.254/latest/meta-data/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := New().EnableSSRFProtection().Get(tt.url).Do().Close()
+			if len(errs) == 0 || !errors.Is(errs[0], ErrBlockedDestination) {
+				t.Fatalf("want ErrBlockedDestination, got %v", errs)
+			}
+		})
+	}
+
+	if errs := New().Get(srv.URL).Do().Close(); len(errs) != 0 {
+		t.Fatalf("unprotected client: %v", errs)
+	}
+}
+
+func TestSSRFProtectionRefusesHTTP3(t *testing.T) {
+	c := New().EnableSSRFProtection()
+	c.http3 = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("request sent over HTTP/3")
+		return nil, nil
+	})
+	errs := c.Get("https://example.com/").Do().Close()
+	if len(errs) == 0 || !errors.Is(errs[0], ErrSSRFOverHTTP3) {
+		t.Fatalf("want ErrSSRFOverHTTP3, got %v", errs)
+	}
+}
@@ -0,0 +1,74 @@
+package httgo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// roundTripFresh sends req and, when an idempotent request fails on a reused keep-alive
+// connection the server already closed, retries it once on a fresh connection
+func (c *HTTPClient) roundTripFresh(req *http.Request) (*http.Response, error) {
+	t := c.currentTransport()
+	if c.h2c != nil && req.URL.Scheme == "http" {
+		t = c.h2c.transport(t)
+	}
+	if !replayable(req) {
+		return t.RoundTrip(req)
+	}
+
+	var reused bool
+	var conn *statsConn
+	treq := req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused = info.Reused
+			conn = unwrapStatsConn(info.Conn)
+		},
+	}))
+
+	res, err := t.RoundTrip(treq)
+	if err == nil || !reused || !staleConnError(err) || req.Context().Err() != nil {
+		return res, err
+	}
+
+	// the other pooled connections to the same address are likely stale as well,
+	// those of other hosts are left alone
+	if conn != nil {
+		conn.host.closeIdle()
+	}
+
+	atomic.AddUint64(&c.stats.retries, 1)
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t.RoundTrip(retry)
+}
+
+// replayable reports whether req is idempotent and its body can be sent again
+func replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// staleConnError reports errors caused by writing to or reading from a connection closed by the peer
+func staleConnError(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		strings.Contains(err.Error(), "server closed idle connection")
+}
@@ -0,0 +1,47 @@
+package httgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloseIdleScopedToHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	a := httptest.NewServer(handler)
+	defer a.Close()
+	b := httptest.NewServer(handler)
+	defer b.Close()
+
+	c := New()
+	for _, u := range []string{a.URL, b.URL} {
+		if errs := c.Get(u).Do().Close(); len(errs) != 0 {
+			t.Fatal(errs)
+		}
+	}
+
+	addrA, addrB := strings.TrimPrefix(a.URL, "http://"), strings.TrimPrefix(b.URL, "http://")
+	idle := func(addr string, want int64) {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			if c.TransportStats().Hosts[addr].Idle == want {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("%s idle connections = %d, want %d", addr, c.TransportStats().Hosts[addr].Idle, want)
+	}
+	idle(addrA, 1)
+	idle(addrB, 1)
+
+	c.stats.host(addrA).closeIdle()
+	idle(addrA, 0)
+	idle(addrB, 1)
+
+	// the transport dropped the closed connection from its pool
+	if errs := c.Get(a.URL).Do().Close(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+}
@@ -0,0 +1,248 @@
+package httgo
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the client's activity, e.g. to expose on an admin endpoint
+type Stats struct {
+	// InFlight is the number of requests waiting for their response headers
+	InFlight int64
+	// Requests is the number of requests sent, cache hits excluded
+	Requests uint64
+	// QPS is the average number of requests sent per second over the last 10 seconds
+	QPS float64
+	// Retries counts the attempts beyond the first, from SetRetry, FetchAll,
+	// precondition retries and replays after stale connections
+	Retries uint64
+	// OpenBodies is the number of response bodies neither closed nor read to the end, event streams excluded
+	OpenBodies int64
+	// QueueDepth is the number of attempts waiting for the rate limiters
+	QueueDepth  int64
+	CacheHits   uint64
+	CacheMisses uint64
+	// Hosts holds per host (as in URL.Host, or the dialed address for connections) counters
+	Hosts map[string]HostStats
+}
+
+// HostStats are the counters of a single host
+type HostStats struct {
+	InFlight int64
+	// Connections is the number of open connections dialed to the host
+	Connections int64
+}
+
+// clientStats holds the counters behind Stats, they are always maintained
+type clientStats struct {
+	inFlight    int64
+	requests    uint64
+	retries     uint64
+	queued      int64
+	openBodies  int64
+	cacheHits   uint64
+	cacheMisses uint64
+
+	mu     sync.Mutex
+	hosts  map[string]*hostStats
+	window [qpsWindow]qpsBucket
+}
+
+type hostStats struct {
+	inFlight    int64
+	connections int64
+	idle        int64
+
+	mu    sync.Mutex
+	conns map[*statsConn]struct{}
+}
+
+type qpsBucket struct {
+	sec int64
+	n   uint64
+}
+
+// statsConn decrements the connection count of its host once closed
+type statsConn struct {
+	net.Conn
+	host   *hostStats
+	closed int32
+	idle   int32
+}
+
+// statsBody decrements the open body count once closed or read to EOF,
+// which already releases the connection
+type statsBody struct {
+	io.ReadCloser
+	stats  *clientStats
+	closed int32
+}
+
+const qpsWindow = 10
+
+func newClientStats() *clientStats {
+	return &clientStats{
+		hosts: make(map[string]*hostStats),
+	}
+}
+
+// Stats returns a snapshot of the client's counters
+func (c *HTTPClient) Stats() Stats {
+	s := c.stats
+	st := Stats{
+		InFlight:    atomic.LoadInt64(&s.inFlight),
+		Requests:    atomic.LoadUint64(&s.requests),
+		Retries:     atomic.LoadUint64(&s.retries),
+		OpenBodies:  atomic.LoadInt64(&s.openBodies),
+		QueueDepth:  atomic.LoadInt64(&s.queued),
+		CacheHits:   atomic.LoadUint64(&s.cacheHits),
+		CacheMisses: atomic.LoadUint64(&s.cacheMisses),
+		Hosts:       make(map[string]HostStats),
+	}
+
+	now := time.Now().Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n uint64
+	for _, b := range s.window {
+		if now-b.sec < qpsWindow {
+			n += b.n
+		}
+	}
+	st.QPS = float64(n) / qpsWindow
+	for host, h := range s.hosts {
+		hs := HostStats{
+			InFlight:    atomic.LoadInt64(&h.inFlight),
+			Connections: atomic.LoadInt64(&h.connections),
+		}
+		if hs != (HostStats{}) {
+			st.Hosts[host] = hs
+		}
+	}
+	return st
+}
+
+// CacheHitRate returns the share of cache lookups which hit, 0 without lookups
+func (s Stats) CacheHitRate() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+func (s *clientStats) host(host string) *hostStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.hosts[host]
+	if !ok {
+		h = new(hostStats)
+		s.hosts[host] = h
+	}
+	return h
+}
+
+// begin records a request sent to host and returns the function recording its end
+func (s *clientStats) begin(host string) func() {
+	atomic.AddUint64(&s.requests, 1)
+	atomic.AddInt64(&s.inFlight, 1)
+	h := s.host(host)
+	atomic.AddInt64(&h.inFlight, 1)
+
+	now := time.Now().Unix()
+	s.mu.Lock()
+	b := &s.window[now%qpsWindow]
+	if b.sec != now {
+		b.sec = now
+		b.n = 0
+	}
+	b.n++
+	s.mu.Unlock()
+
+	return func() {
+		atomic.AddInt64(&s.inFlight, -1)
+		atomic.AddInt64(&h.inFlight, -1)
+	}
+}
+
+func (s *clientStats) conn(addr string, conn net.Conn) net.Conn {
+	h := s.host(addr)
+	atomic.AddInt64(&h.connections, 1)
+	sc := &statsConn{
+		Conn: conn,
+		host: h,
+	}
+	h.mu.Lock()
+	if h.conns == nil {
+		h.conns = make(map[*statsConn]struct{})
+	}
+	h.conns[sc] = struct{}{}
+	h.mu.Unlock()
+	return sc
+}
+
+// closeIdle closes the connections of the host waiting in the pool, the transport
+// drops them from its pool as soon as it notices
+func (h *hostStats) closeIdle() {
+	var idle []*statsConn
+	h.mu.Lock()
+	for sc := range h.conns {
+		if atomic.LoadInt32(&sc.idle) == 1 {
+			idle = append(idle, sc)
+		}
+	}
+	h.mu.Unlock()
+	for _, sc := range idle {
+		sc.Close()
+	}
+}
+
+func (sc *statsConn) Close() error {
+	if atomic.CompareAndSwapInt32(&sc.closed, 0, 1) {
+		sc.setIdle(false)
+		atomic.AddInt64(&sc.host.connections, -1)
+		sc.host.mu.Lock()
+		delete(sc.host.conns, sc)
+		sc.host.mu.Unlock()
+	}
+	return sc.Conn.Close()
+}
+
+func (sc *statsConn) setIdle(idle bool) {
+	switch {
+	case idle && atomic.LoadInt32(&sc.closed) == 0 && atomic.CompareAndSwapInt32(&sc.idle, 0, 1):
+		atomic.AddInt64(&sc.host.idle, 1)
+	case !idle && atomic.CompareAndSwapInt32(&sc.idle, 1, 0):
+		atomic.AddInt64(&sc.host.idle, -1)
+	}
+}
+
+func (s *clientStats) body(body io.ReadCloser) io.ReadCloser {
+	atomic.AddInt64(&s.openBodies, 1)
+	return &statsBody{
+		ReadCloser: body,
+		stats:      s,
+	}
+}
+
+func (sb *statsBody) Read(p []byte) (int, error) {
+	n, err := sb.ReadCloser.Read(p)
+	if err == io.EOF {
+		sb.release()
+	}
+	return n, err
+}
+
+func (sb *statsBody) Close() error {
+	sb.release()
+	return sb.ReadCloser.Close()
+}
+
+func (sb *statsBody) release() {
+	if atomic.CompareAndSwapInt32(&sb.closed, 0, 1) {
+		atomic.AddInt64(&sb.stats.openBodies, -1)
+	}
+}
@@ -0,0 +1,105 @@
+package httgo
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// MisuseError is reported in strict mode for builder calls which would
+// otherwise produce a silently wrong request or response, see EnableStrictMode
+type MisuseError struct {
+	Method string
+	Reason string
+}
+
+var (
+	// ErrMisuse matches *MisuseError with errors.Is
+	ErrMisuse = errors.New("Builder Misuse")
+)
+
+// EnableStrictMode reports builder misuse as *MisuseError in the Build phase instead of tolerating it:
+// reading the body with a second terminal method, sending the request twice, a body on GET or HEAD,
+// headers, cookies or credentials set after the request was sent, and a RequestBuilder used from
+// several goroutines at once, e.g. one kept next to the singleton client of GetHTTPClient
+func (c *HTTPClient) EnableStrictMode() *HTTPClient {
+	c.strict = true
+	return c
+}
+
+func (e *MisuseError) Error() string {
+	return ErrMisuse.Error() + ": " + e.Method + " " + e.Reason
+}
+
+// Unwrap returns ErrMisuse
+func (e *MisuseError) Unwrap() error {
+	return ErrMisuse
+}
+
+func (b *RequestBuilder) misuse(method, reason string) {
+	b.fail(ErrPhaseBuild, &MisuseError{
+		Method: method,
+		Reason: reason,
+	})
+}
+
+// consume records method as the terminal method reading the body, in strict mode it
+// refuses a body already read by another one. Methods keeping the body readable pass keep.
+func (b *RequestBuilder) consume(method string, keep bool) bool {
+	if !b.client.strict {
+		return true
+	}
+	if b.consumedBy != "" {
+		b.misuse(method, "reads the body already read by "+b.consumedBy)
+		return false
+	}
+	if !keep {
+		b.consumedBy = method
+	}
+	return true
+}
+
+// unsent reports changes to a request already sent, which have no effect, in strict mode
+func (b *RequestBuilder) unsent(method string) {
+	if b.client.strict && b.isRequested {
+		b.misuse(method, "called after the request was sent")
+	}
+}
+
+// checkSend refuses in strict mode to send a request twice or from several goroutines at once,
+// the returned function ends the send
+func (b *RequestBuilder) checkSend() (func(), bool) {
+	if !b.client.strict {
+		return func() {}, true
+	}
+	if !atomic.CompareAndSwapInt32(&b.sending, 0, 1) {
+		b.misuse("Do", "called concurrently from several goroutines")
+		return nil, false
+	}
+	if b.isRequested {
+		atomic.StoreInt32(&b.sending, 0)
+		b.misuse("Do", "called after the request was sent")
+		return nil, false
+	}
+	return func() {
+		atomic.StoreInt32(&b.sending, 0)
+	}, true
+}
+
+// checkBody refuses in strict mode a body on methods whose body has no defined meaning
+func (b *RequestBuilder) checkBody() bool {
+	if !b.client.strict || b.body == nil {
+		return true
+	}
+	switch b.method {
+	case http.MethodGet, http.MethodHead:
+		b.misuse(b.method, "request with a body")
+		return false
+	case http.MethodDelete:
+		if !b.deleteBody {
+			b.misuse(b.method, "request with a body, use DeleteWithBody")
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package httgo
+
+import (
+	"bytes"
+	"html"
+	"mime"
+	"strings"
+)
+
+// htmlSkipTags are elements whose content is never readable text
+var htmlSkipTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"iframe":   true,
+	"object":   true,
+	"head":     true,
+}
+
+var htmlNewlines = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// htmlBlockTags are elements rendered on their own line
+var htmlBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "td": true, "th": true,
+	"title": true, "tr": true, "ul": true,
+}
+
+// Text returns the readable text of the response: the body is decoded from its charset,
+// and for HTML, scripts, styles, comments and markup are removed, entities are unescaped
+// and whitespace is collapsed with one line per block element
+func (b *RequestBuilder) Text() (string, []error) {
+	res := b.response()
+	if res == nil {
+		return "", b.errs
+	}
+
+	body, errs := b.GetByteBody()
+	if len(body) == 0 {
+		return "", errs
+	}
+
+	ct := res.Header.Get("Content-Type")
+	data, err := decodeCharset(body, detectCharset(body, ct))
+	if err != nil {
+		b.fail(ErrPhaseDecode, err)
+		return "", b.errs
+	}
+
+	mt, _, _ := mime.ParseMediaType(ct)
+	if mt == "text/html" || mt == "application/xhtml+xml" || mt == "" && looksLikeHTML(data) {
+		return htmlToText(string(data)), b.errs
+	}
+	return string(data), b.errs
+}
+
+func looksLikeHTML(data []byte) bool {
+	head := bytes.ToLower(bytes.TrimSpace(data))
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html"))
+}
+
+// htmlToText strips markup from s, it is tolerant of malformed documents
+func htmlToText(s string) string {
+	var buf strings.Builder
+	text := func(t string) {
+		// line breaks in the source are plain whitespace, block elements break lines
+		buf.WriteString(htmlNewlines.Replace(html.UnescapeString(t)))
+	}
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			text(s)
+			break
+		}
+		text(s[:i])
+		s = s[i:]
+
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				break
+			}
+			s = s[end+3:]
+			continue
+		}
+
+		end := tagEnd(s)
+		if end < 0 {
+			break
+		}
+		tag := s[1:end]
+		s = s[end+1:]
+
+		closing := strings.HasPrefix(tag, "/")
+		name := strings.ToLower(strings.TrimLeft(tag, "/"))
+		if n := strings.IndexAny(name, " \t\r\n/"); n >= 0 {
+			name = name[:n]
+		}
+
+		if htmlSkipTags[name] && !closing && !strings.HasSuffix(tag, "/") {
+			s = skipElement(s, name)
+			continue
+		}
+		if htmlBlockTags[name] {
+			buf.WriteByte('\n')
+		}
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// tagEnd returns the index of the '>' closing the tag at the start of s, skipping quoted attributes
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// skipElement returns s after the closing tag of name, or "" when it is not closed
+func skipElement(s, name string) string {
+	lower := strings.ToLower(s)
+	i := strings.Index(lower, "</"+name)
+	if i < 0 {
+		return ""
+	}
+	end := strings.IndexByte(s[i:], '>')
+	if end < 0 {
+		return ""
+	}
+	return s[i+end+1:]
+}
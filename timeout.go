@@ -0,0 +1,98 @@
+package httgo
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimeoutBody aborts the underlying body when a single Read blocks
+// longer than timeout, so slow but progressing streams are not killed.
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	expired int32
+}
+
+// cancelBody releases the request context once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// SetTimeout sets the total timeout of every request sent by this client
+func (c *HTTPClient) SetTimeout(t time.Duration) *HTTPClient {
+	c.client.Timeout = t
+	return c
+}
+
+// SetRequestTimeout sets the deadline of the current request only,
+// covering everything from dialing to reading the response body
+func (b *RequestBuilder) SetRequestTimeout(d time.Duration) *RequestBuilder {
+	b.timeout = d
+	return b
+}
+
+// SetDialTimeout limits the time spent establishing new connections
+func (c *HTTPClient) SetDialTimeout(d time.Duration) *HTTPClient {
+	c.dialer.Timeout = d
+	return c
+}
+
+// SetTLSHandshakeTimeout limits the time spent on TLS handshakes
+func (c *HTTPClient) SetTLSHandshakeTimeout(d time.Duration) *HTTPClient {
+	c.transport.TLSHandshakeTimeout = d
+	return c
+}
+
+// SetResponseHeaderTimeout limits the time waiting for response headers after the request is written
+func (c *HTTPClient) SetResponseHeaderTimeout(d time.Duration) *HTTPClient {
+	c.transport.ResponseHeaderTimeout = d
+	return c
+}
+
+// SetBodyReadTimeout aborts the response body when no data arrives for d between reads
+func (c *HTTPClient) SetBodyReadTimeout(d time.Duration) *HTTPClient {
+	c.bodyReadTimeout = d
+	return c
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{
+		body:    body,
+		timeout: timeout,
+	}
+	b.timer = time.AfterFunc(timeout, b.expire)
+	b.timer.Stop()
+	return b
+}
+
+func (b *idleTimeoutBody) expire() {
+	atomic.StoreInt32(&b.expired, 1)
+	b.body.Close()
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	if atomic.LoadInt32(&b.expired) == 1 {
+		return 0, ErrBodyReadTimeout
+	}
+	b.timer.Reset(b.timeout)
+	n, err := b.body.Read(p)
+	if !b.timer.Stop() && atomic.LoadInt32(&b.expired) == 1 {
+		return n, ErrBodyReadTimeout
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,124 @@
+package httgo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases reported by TimeoutError
+const (
+	TimeoutPhaseDNS     = "dns"
+	TimeoutPhaseConnect = "connect"
+	TimeoutPhaseTLS     = "tls"
+	TimeoutPhaseSend    = "send"
+	TimeoutPhaseTTFB    = "ttfb"
+	TimeoutPhaseBody    = "body"
+)
+
+// TimeoutError is a timeout annotated with the phase which exceeded its budget,
+// as observed by a trace of the request, and the time elapsed since it was sent
+type TimeoutError struct {
+	Phase   string
+	Elapsed time.Duration
+	Err     error
+}
+
+// phaseTrace tracks which phase the current attempt of a request is in
+type phaseTrace struct {
+	mu    sync.Mutex
+	start time.Time
+	phase string
+}
+
+func (e *TimeoutError) Error() string {
+	return "Timeout in " + e.Phase + " phase after " + e.Elapsed.Round(time.Millisecond).String() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error, e.g. context.DeadlineExceeded
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports true, as net.Error
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+func newPhaseTrace() *phaseTrace {
+	return &phaseTrace{
+		start: time.Now(),
+		phase: TimeoutPhaseConnect,
+	}
+}
+
+func (p *phaseTrace) set(phase string) {
+	p.mu.Lock()
+	p.phase = phase
+	p.mu.Unlock()
+}
+
+func (p *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			p.set(TimeoutPhaseConnect)
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.set(TimeoutPhaseDNS)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			p.set(TimeoutPhaseConnect)
+		},
+		TLSHandshakeStart: func() {
+			p.set(TimeoutPhaseTLS)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			p.set(TimeoutPhaseSend)
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			p.set(TimeoutPhaseSend)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			p.set(TimeoutPhaseTTFB)
+		},
+		GotFirstResponseByte: func() {
+			p.set(TimeoutPhaseBody)
+		},
+	}
+}
+
+// timeoutError annotates err with the phase it timed out in, body errors always
+// belong to the body phase. Other errors are returned as is.
+func (p *phaseTrace) timeoutError(phase, err error) error {
+	if p == nil || !isTimeout(err) {
+		return err
+	}
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		return err
+	}
+
+	p.mu.Lock()
+	tp := p.phase
+	p.mu.Unlock()
+	if phase == ErrPhaseBody || phase == ErrPhaseDecode {
+		tp = TimeoutPhaseBody
+	}
+	return &TimeoutError{
+		Phase:   tp,
+		Elapsed: time.Since(p.start),
+		Err:     err,
+	}
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrBodyReadTimeout) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,94 @@
+package httgo
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrCertificatePinMismatch is returned when no certificate of the server chain matches a pin
+	ErrCertificatePinMismatch = errors.New("Certificate Pin Mismatch")
+	// ErrKeyLogNotAllowed is returned by SetKeyLogWriter unless AllowUnsafeKeyLog was called before
+	ErrKeyLogNotAllowed = errors.New("TLS Key Log Not Allowed")
+)
+
+// SetClientCert loads a PEM encoded certificate and key pair presented to servers requesting mTLS
+func (c *HTTPClient) SetClientCert(certFile, keyFile string) *HTTPClient {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		c.fail(ErrPhaseBuild, err)
+		return c
+	}
+	cfg := c.tlsConfig()
+	cfg.Certificates = append(cfg.Certificates, cert)
+	return c
+}
+
+// SetRootCAs verifies servers against pool instead of the system roots
+func (c *HTTPClient) SetRootCAs(pool *x509.CertPool) *HTTPClient {
+	c.tlsConfig().RootCAs = pool
+	return c
+}
+
+// SetCertificatePinning requires a certificate of the verified server chain to match one of
+// sha256Pins, the base64 SHA-256 of its SubjectPublicKeyInfo, optionally prefixed with "sha256/"
+// as printed by `openssl x509 -pubkey | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64`.
+// Connections whose chain was not verified, e.g. with InsecureSkipVerify, never match.
+func (c *HTTPClient) SetCertificatePinning(sha256Pins []string) *HTTPClient {
+	pins := make(map[string]bool, len(sha256Pins))
+	for _, p := range sha256Pins {
+		pins[strings.TrimPrefix(p, "sha256/")] = true
+	}
+
+	c.tlsConfig().VerifyConnection = func(cs tls.ConnectionState) error {
+		// only chains built from the system or SetRootCAs roots count, the server may send any
+		// certificate along, and nothing is verified with InsecureSkipVerify which then always fails
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				if pins[base64.StdEncoding.EncodeToString(sum[:])] {
+					return nil
+				}
+			}
+		}
+		return ErrCertificatePinMismatch
+	}
+	return c
+}
+
+// InsecureSkipVerify disables server certificate verification, only use it for testing
+func (c *HTTPClient) InsecureSkipVerify() *HTTPClient {
+	c.tlsConfig().InsecureSkipVerify = true
+	return c
+}
+
+// AllowUnsafeKeyLog permits SetKeyLogWriter. Key logs let anyone holding them decrypt the
+// captured traffic, keep this behind an explicit debugging switch
+func (c *HTTPClient) AllowUnsafeKeyLog() *HTTPClient {
+	c.unsafeKeyLog = true
+	return c
+}
+
+// SetKeyLogWriter writes the TLS secrets of new connections to w in NSS key log format, the
+// SSLKEYLOGFILE Wireshark reads to decrypt captures. It fails with ErrKeyLogNotAllowed unless
+// AllowUnsafeKeyLog was called first. Connections already open are not logged, see CloseIdleConnections.
+func (c *HTTPClient) SetKeyLogWriter(w io.Writer) *HTTPClient {
+	if !c.unsafeKeyLog {
+		c.fail(ErrPhaseBuild, ErrKeyLogNotAllowed)
+		return c
+	}
+	c.tlsConfig().KeyLogWriter = w
+	return c
+}
+
+func (c *HTTPClient) tlsConfig() *tls.Config {
+	if c.transport.TLSClientConfig == nil {
+		c.transport.TLSClientConfig = new(tls.Config)
+	}
+	return c.transport.TLSClientConfig
+}
@@ -0,0 +1,42 @@
+package httgo
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetCertificatePinning(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	pin := "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	tests := []struct {
+		name   string
+		client *HTTPClient
+		ok     bool
+	}{
+		{"verified chain matching the pin", New().SetRootCAs(pool).SetCertificatePinning([]string{pin}), true},
+		{"verified chain not matching", New().SetRootCAs(pool).SetCertificatePinning([]string{"AAAA"}), false},
+		{"unverified chain matching the pin", New().InsecureSkipVerify().SetCertificatePinning([]string{pin}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.client.Get(srv.URL).Do().Close()
+			if tt.ok && len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if !tt.ok && (len(errs) == 0 || !errors.Is(errs[0], ErrCertificatePinMismatch)) {
+				t.Fatalf("want ErrCertificatePinMismatch, got %v", errs)
+			}
+		})
+	}
+}
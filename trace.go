@@ -0,0 +1,166 @@
+package httgo
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TraceInfo holds the timing of each phase of a request
+type TraceInfo struct {
+	DNSLookup        time.Duration
+	TCPConnect       time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration
+	TTFB             time.Duration
+	Total            time.Duration
+	ConnReused       bool
+	CacheStatus      string
+	// RewrittenFrom is the URL before AddRewriteRule rewrote it, empty when no rule matched
+	RewrittenFrom string
+}
+
+// traceBody marks the end of the request once the body is fully read or closed
+type traceBody struct {
+	io.ReadCloser
+	tracer *tracer
+}
+
+// EnableTrace collects per-phase timings of every request, see RequestBuilder.GetTrace
+func (c *HTTPClient) EnableTrace() *HTTPClient {
+	c.traceEnabled = true
+	return c
+}
+
+// EnableTrace collects per-phase timings of this request
+func (b *RequestBuilder) EnableTrace() *RequestBuilder {
+	if b.tracer == nil {
+		b.tracer = newTracer()
+	}
+	return b
+}
+
+// GetTrace returns the phase timings of a traced request.
+// Total covers the body as well once it has been read or closed.
+func (b *RequestBuilder) GetTrace() TraceInfo {
+	if b.tracer == nil {
+		return TraceInfo{
+			CacheStatus:   b.cacheStatus,
+			RewrittenFrom: b.rewrittenFrom,
+		}
+	}
+	info := b.tracer.info()
+	info.CacheStatus = b.cacheStatus
+	info.RewrittenFrom = b.rewrittenFrom
+	return info
+}
+
+// tracer collects httptrace events of a single request
+type tracer struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connStart    time.Time
+	connDone     time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	end          time.Time
+	reused       bool
+}
+
+func newTracer() *tracer {
+	return &tracer{
+		start: time.Now(),
+	}
+}
+
+func (t *tracer) mark(p *time.Time) {
+	t.mu.Lock()
+	if p.IsZero() {
+		*p = time.Now()
+	}
+	t.mu.Unlock()
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mark(&t.dnsDone)
+		},
+		ConnectStart: func(string, string) {
+			t.mark(&t.connStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.mark(&t.connDone)
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mark(&t.tlsDone)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mark(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+		},
+	}
+}
+
+func (t *tracer) done() {
+	t.mark(&t.end)
+}
+
+func (t *tracer) info() TraceInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := t.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	return TraceInfo{
+		DNSLookup:        between(t.dnsStart, t.dnsDone),
+		TCPConnect:       between(t.connStart, t.connDone),
+		TLSHandshake:     between(t.tlsStart, t.tlsDone),
+		ServerProcessing: between(t.wroteRequest, t.firstByte),
+		TTFB:             between(t.start, t.firstByte),
+		Total:            end.Sub(t.start),
+		ConnReused:       t.reused,
+	}
+}
+
+func (t *traceBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if err == io.EOF {
+		t.tracer.done()
+	}
+	return n, err
+}
+
+func (t *traceBody) Close() error {
+	t.tracer.done()
+	return t.ReadCloser.Close()
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
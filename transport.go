@@ -0,0 +1,108 @@
+package httgo
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Middleware wraps the transport used for every attempt, including redirect hops and retries,
+// AttemptFromContext tells the attempt a request belongs to
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// roundTripper is installed as the http.Client transport so that every attempt,
+// including redirect hops, passes through the client hooks before reaching c.transport
+type roundTripper struct {
+	client *HTTPClient
+}
+
+// Use appends middlewares, the first one registered is the outermost
+func (c *HTTPClient) Use(mws ...Middleware) *HTTPClient {
+	c.middlewares = append(c.middlewares, mws...)
+	var rt http.RoundTripper = roundTripperFunc(c.attempt)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		rt = c.middlewares[i](rt)
+	}
+	c.handler = rt
+	return c
+}
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := rt.client
+	if c.hostPool != nil {
+		return c.hostPool.roundTrip(req, c.retriedRoundTrip)
+	}
+	return c.retriedRoundTrip(req)
+}
+
+// retriedRoundTrip sends req, retried as configured by SetRetry, each attempt through the middlewares
+func (c *HTTPClient) retriedRoundTrip(req *http.Request) (*http.Response, error) {
+	return c.retryRoundTrip(req, c.chain)
+}
+
+func (c *HTTPClient) chain(req *http.Request) (*http.Response, error) {
+	if c.handler != nil {
+		return c.handler.RoundTrip(req)
+	}
+	return c.attempt(req)
+}
+
+// attempt sends a single attempt through the circuit breaker once its destination is allowed,
+// it is checked after the middlewares which may have changed it
+func (c *HTTPClient) attempt(req *http.Request) (*http.Response, error) {
+	err := c.checkDestination(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.breakers != nil {
+		return c.breakers.roundTrip(req, c.send)
+	}
+	return c.send(req)
+}
+
+func (c *HTTPClient) send(req *http.Request) (*http.Response, error) {
+	err := c.waitRateLimit(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.attemptHeader {
+		req = setAttemptHeader(req)
+	}
+
+	req = c.stripProxied(req)
+
+	if c.signer != nil {
+		req, err = c.sign(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	next := c.baseRoundTrip
+	if c.logger != nil {
+		next = c.debugRoundTrip
+	}
+
+	var proxy *url.URL
+	if c.proxyAuth != nil {
+		proxy = c.proxyFor(req)
+	}
+
+	var res *http.Response
+	if proxy != nil {
+		res, err = c.proxyAuth.roundTrip(req, proxy, next)
+	} else {
+		res, err = next(req)
+	}
+
+	if c.rateStore != nil && res != nil {
+		c.rateStore.coolDown(res, req.URL.Host, c.hostLimiter(req.URL.Host))
+	}
+	return res, err
+}
@@ -0,0 +1,46 @@
+package httgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetURLTemplateFunc names the route of each request with fn, e.g. "/users/{id}" for "/users/12345",
+// reported as MetricsLabels.Route, in OTel span names and url.template attributes, and as the endpoint
+// of SchemaDrift, so per-route metrics do not explode into one series per ID. CollapseIDs is a ready-made fn.
+func (c *HTTPClient) SetURLTemplateFunc(fn func(*http.Request) string) *HTTPClient {
+	c.urlTemplate = fn
+	return c
+}
+
+// CollapseIDs returns the path of req with numeric, UUID and long hexadecimal segments replaced with "{id}"
+func CollapseIDs(req *http.Request) string {
+	segs := strings.Split(req.URL.Path, "/")
+	for i, s := range segs {
+		if isIDSegment(s) {
+			segs[i] = "{id}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func isIDSegment(s string) bool {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return false
+	case strings.Trim(s, "0123456789") == "":
+		return true
+	case len(s) == 36 && strings.Count(s, "-") == 4:
+		return strings.Trim(lower, "0123456789abcdef-") == ""
+	}
+	return len(s) >= 16 && strings.Trim(lower, "0123456789abcdef") == ""
+}
+
+// routeOf returns the URL template of req, "" without SetURLTemplateFunc
+func (c *HTTPClient) routeOf(req *http.Request) string {
+	if c.urlTemplate == nil {
+		return ""
+	}
+	return c.urlTemplate(req)
+}
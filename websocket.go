@@ -0,0 +1,273 @@
+package httgo
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WebSocket message types as defined by RFC 6455
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+const (
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessageSize = 32 << 20
+)
+
+// WSConn is a client WebSocket connection established by UpgradeWebSocket
+type WSConn struct {
+	rwc  io.ReadWriteCloser
+	br   *bufio.Reader
+	wmu  sync.Mutex
+	res  *http.Response
+	once sync.Once
+}
+
+var (
+	// ErrBadHandshake is returned when the server refuses the WebSocket upgrade
+	ErrBadHandshake = errors.New("WebSocket Bad Handshake")
+	// ErrWebSocketClosed is returned once a close frame has been received
+	ErrWebSocketClosed = errors.New("WebSocket Closed")
+	// ErrWebSocketProtocol is returned for malformed frames
+	ErrWebSocketProtocol = errors.New("WebSocket Protocol Error")
+	// ErrWebSocketMessageTooLarge is returned for messages over the size limit
+	ErrWebSocketMessageTooLarge = errors.New("WebSocket Message Too Large")
+)
+
+// UpgradeWebSocket performs the WebSocket handshake using the client's transport,
+// so TLS settings, proxy, cookie jar, headers and auth are shared with plain requests.
+func (b *RequestBuilder) UpgradeWebSocket(ctx context.Context) (*WSConn, []error) {
+	switch {
+	case strings.HasPrefix(b.url, "ws://"):
+		b.url = "http://" + strings.TrimPrefix(b.url, "ws://")
+	case strings.HasPrefix(b.url, "wss://"):
+		b.url = "https://" + strings.TrimPrefix(b.url, "wss://")
+	}
+
+	kb := make([]byte, 16)
+	_, err := io.ReadFull(rand.Reader, kb)
+	if err != nil {
+		b.fail(ErrPhaseBuild, err)
+		return nil, b.errs
+	}
+	key := base64.StdEncoding.EncodeToString(kb)
+
+	b.method = http.MethodGet
+	b.body = nil
+	b.stream = true
+	b.header.Set("Connection", "Upgrade")
+	b.header.Set("Upgrade", "websocket")
+	b.header.Set("Sec-WebSocket-Version", "13")
+	b.header.Set("Sec-WebSocket-Key", key)
+
+	b.newRequest()
+	if !b.isRequestReady {
+		return nil, b.errs
+	}
+	b.req = b.req.WithContext(ctx)
+
+	res, err := b.client.client.Do(b.req)
+	if err != nil {
+		b.fail(transportPhase(err), err)
+		return nil, b.errs
+	}
+	b.res = res
+	b.isRequested = true
+
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if res.StatusCode != http.StatusSwitchingProtocols ||
+		!strings.EqualFold(res.Header.Get("Upgrade"), "websocket") ||
+		res.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) ||
+		!ok {
+		res.Body.Close()
+		b.fail(ErrPhaseResponse, ErrBadHandshake)
+		return nil, b.errs
+	}
+
+	return &WSConn{
+		rwc: rwc,
+		br:  bufio.NewReader(rwc),
+		res: res,
+	}, b.errs
+}
+
+// Response returns the handshake response
+func (w *WSConn) Response() *http.Response {
+	return w.res
+}
+
+// ReadMessage reads the next data message, answering pings transparently
+func (w *WSConn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, op, payload, err := w.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case PingMessage:
+			err = w.writeFrame(PongMessage, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			w.writeFrame(CloseMessage, payload)
+			w.rwc.Close()
+			return CloseMessage, payload, ErrWebSocketClosed
+		case TextMessage, BinaryMessage:
+		default:
+			return 0, nil, ErrWebSocketProtocol
+		}
+
+		messageType, data = op, payload
+		for !fin {
+			var cop int
+			fin, cop, payload, err = w.readFrame()
+			if err != nil {
+				return 0, nil, err
+			}
+			switch cop {
+			case PingMessage:
+				err = w.writeFrame(PongMessage, payload)
+				if err != nil {
+					return 0, nil, err
+				}
+				fin = false
+				continue
+			case PongMessage:
+				fin = false
+				continue
+			case 0:
+			default:
+				return 0, nil, ErrWebSocketProtocol
+			}
+			if len(data)+len(payload) > wsMaxMessageSize {
+				return 0, nil, ErrWebSocketMessageTooLarge
+			}
+			data = append(data, payload...)
+		}
+		return messageType, data, nil
+	}
+}
+
+// WriteMessage sends data as a single frame of the given message type
+func (w *WSConn) WriteMessage(messageType int, data []byte) error {
+	return w.writeFrame(messageType, data)
+}
+
+// Close sends a close frame and closes the underlying connection
+func (w *WSConn) Close() error {
+	var err error
+	w.once.Do(func() {
+		w.writeFrame(CloseMessage, []byte{0x03, 0xe8})
+		err = w.rwc.Close()
+	})
+	return err
+}
+
+func (w *WSConn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var h [2]byte
+	_, err = io.ReadFull(w.br, h[:])
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = h[0]&0x80 != 0
+	op = int(h[0] & 0x0f)
+	masked := h[1]&0x80 != 0
+	n := uint64(h[1] & 0x7f)
+
+	switch n {
+	case 126:
+		var ext [2]byte
+		_, err = io.ReadFull(w.br, ext[:])
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, err = io.ReadFull(w.br, ext[:])
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if n > wsMaxMessageSize {
+		return false, 0, nil, ErrWebSocketMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		_, err = io.ReadFull(w.br, mask[:])
+		if err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, n)
+	_, err = io.ReadFull(w.br, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, op, payload, nil
+}
+
+func (w *WSConn) writeFrame(op int, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|byte(op))
+
+	n := len(payload)
+	switch {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(frame, 0x80|127)
+		frame = append(frame, ext[:]...)
+	}
+
+	var mask [4]byte
+	_, err := io.ReadFull(rand.Reader, mask[:])
+	if err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	_, err = w.rwc.Write(frame)
+	return err
+}
+
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
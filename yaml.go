@@ -0,0 +1,42 @@
+//go:build yaml
+// +build yaml
+
+package httgo
+
+import (
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML decoding is only available when building with the yaml tag
+func init() {
+	decode := func(r io.Reader, v interface{}) error {
+		return yaml.NewDecoder(r).Decode(v)
+	}
+	for _, ct := range []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"} {
+		decoders[ct] = decode
+	}
+}
+
+// LoadConfigYAML reads a Config from the YAML file at path, keys are the yaml tags of Config
+// and unknown keys are rejected
+func LoadConfigYAML(path string) (Config, error) {
+	var cfg Config
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	err = dec.Decode(&cfg)
+	if err != nil && err != io.EOF {
+		return cfg, &ConfigError{
+			Field:  path,
+			Reason: err.Error(),
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,40 @@
+//go:build zstd
+// +build zstd
+
+package httgo
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstd decoding is only available when building with the zstd tag
+func init() {
+	contentDecoders["zstd"] = func(r io.Reader) (io.Reader, error) {
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+
+	zstdDictDecoder = func(dict []byte) contentDecoder {
+		return func(r io.Reader) (io.Reader, error) {
+			d, err := zstd.NewReader(r, zstd.WithDecoderDicts(dict))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		}
+	}
+
+	zstdDictEncoder = func(data, dict []byte) ([]byte, error) {
+		e, err := zstd.NewWriter(nil, zstd.WithEncoderDict(dict))
+		if err != nil {
+			return nil, err
+		}
+		defer e.Close()
+		return e.EncodeAll(data, nil), nil
+	}
+}
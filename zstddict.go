@@ -0,0 +1,74 @@
+package httgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+var (
+	// zstdDictDecoder and zstdDictEncoder are set by zstd.go when building with the zstd tag
+	zstdDictDecoder func(dict []byte) contentDecoder
+	zstdDictEncoder func(data, dict []byte) ([]byte, error)
+)
+
+// SetZstdDictionary compresses request bodies sent to host (as in URL.Host) with zstd and
+// dict, and decodes its zstd responses with dict, which shrinks repetitive payloads of
+// internal services considerably. dict is a zstd dictionary as built by `zstd --train`.
+// It requires building with the zstd tag, otherwise ErrUnsupportedContentEncoding is raised.
+func (c *HTTPClient) SetZstdDictionary(host string, dict []byte) *HTTPClient {
+	if zstdDictDecoder == nil {
+		c.fail(ErrPhaseBuild, ErrUnsupportedContentEncoding)
+		return c
+	}
+	if c.zstdDicts == nil {
+		c.zstdDicts = make(map[string][]byte)
+	}
+	c.zstdDicts[host] = dict
+	return c
+}
+
+// hostDecoders returns the content decoders replacing the defaults for host
+func (c *HTTPClient) hostDecoders(host string) map[string]contentDecoder {
+	dict, ok := c.zstdDicts[host]
+	if !ok {
+		return nil
+	}
+	return map[string]contentDecoder{
+		"zstd": zstdDictDecoder(dict),
+	}
+}
+
+// responseHost is the host res came from, the last redirect hop's
+func responseHost(req *http.Request, res *http.Response) string {
+	if res.Request != nil {
+		return res.Request.URL.Host
+	}
+	return req.URL.Host
+}
+
+// compressBody encodes the request body with the zstd dictionary of host, if any
+func (b *RequestBuilder) compressBody(host string) error {
+	dict, ok := b.client.zstdDicts[host]
+	if !ok {
+		return nil
+	}
+	if b.header.Get("Accept-Encoding") == "" {
+		b.header.Set("Accept-Encoding", "zstd")
+	}
+	if b.body == nil || b.header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	data, err := ioutil.ReadAll(b.body)
+	if err != nil {
+		return err
+	}
+	data, err = zstdDictEncoder(data, dict)
+	if err != nil {
+		return err
+	}
+	b.body = bytes.NewReader(data)
+	b.bodyStream = false
+	b.header.Set("Content-Encoding", "zstd")
+	return nil
+}